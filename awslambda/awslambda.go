@@ -0,0 +1,54 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+// Handler AWS Lambda 处理函数，签名符合 aws-lambda-go 对处理器的要求
+type Handler[O any] func(ctx context.Context, event json.RawMessage) (O, error)
+
+// Starter 启动 AWS Lambda 运行时的函数，与 aws-lambda-go 的 lambda.Start 签名一致
+type Starter func(handler any)
+
+// NewHandler 创建把事件分发给已注册lambda的处理函数
+// 事件解码为 I 后按名称在全局注册表中查找并调用lambda；每次调用时查找，因此可以在 Start 之后注册。
+// AWS 传入的 context 带有本次调用的截止时间，会原样传递给lambda。
+func NewHandler[I any, O any](name string) Handler[O] {
+	return func(ctx context.Context, event json.RawMessage) (O, error) {
+		var zero O
+
+		var input I
+		if err := json.Unmarshal(event, &input); err != nil {
+			return zero, fmt.Errorf("%w: %v", registry.ErrInvalidPayload, err)
+		}
+
+		lambda, exists := registry.GetLambda[I, O](name)
+		if !exists {
+			return zero, fmt.Errorf("%w: '%s'", core.ErrLambdaNotFound, name)
+		}
+
+		result, err := lambda.Invoke(ctx, input)
+		if err != nil {
+			// 截止时间来自 AWS 而不是lambda自身的超时选项时，同样标记为超时
+			if errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, core.ErrTimeout) {
+				err = fmt.Errorf("%w: %w", core.ErrTimeout, err)
+			}
+			return zero, err
+		}
+		return result.Output, nil
+	}
+}
+
+// Start 以已注册的lambda作为 AWS Lambda 处理器启动运行时
+// starter 通常为 aws-lambda-go 的 lambda.Start：
+//
+//	awslambda.Start[Order, Receipt]("checkout", lambda.Start)
+func Start[I any, O any](name string, starter Starter) {
+	starter(NewHandler[I, O](name))
+}
@@ -0,0 +1,75 @@
+package benchmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// overheadIterations 每次测量的调用次数
+const overheadIterations = 20000
+
+// overheadRounds 每项测量的轮数，取最快一轮以减少调度噪声
+const overheadRounds = 3
+
+// MiddlewareCost 单个中间件的开销测量结果
+type MiddlewareCost struct {
+	Index           int     // 中间件在参数中的位置
+	NSPerOp         float64 // 只包含该中间件的调用链每次调用耗时
+	BaselineNSPerOp float64 // 不含中间件的调用链每次调用耗时
+	OverheadNSPerOp float64 // 该中间件带来的额外耗时，测量噪声导致的负值记为 0
+}
+
+// noopHandler 开销测量使用的空处理器
+func noopHandler(ctx context.Context, x int) (int, error) {
+	return x, nil
+}
+
+// MiddlewareOverhead 在空处理器上分别测量每个中间件单独使用时增加的每次调用耗时
+// 每个中间件单独组成调用链，与不含中间件的调用链对比，返回与 mws 一一对应的结果
+func MiddlewareOverhead(mws ...core.Middleware[int, int]) []MiddlewareCost {
+	baseline := measureNSPerOp(core.NewChain[int, int](noopHandler))
+
+	costs := make([]MiddlewareCost, len(mws))
+	for i, mw := range mws {
+		nsPerOp := measureNSPerOp(core.NewChain(noopHandler, mw))
+
+		overhead := nsPerOp - baseline
+		if overhead < 0 {
+			overhead = 0
+		}
+
+		costs[i] = MiddlewareCost{
+			Index:           i,
+			NSPerOp:         nsPerOp,
+			BaselineNSPerOp: baseline,
+			OverheadNSPerOp: overhead,
+		}
+	}
+
+	return costs
+}
+
+// measureNSPerOp 测量调用链每次调用的耗时（纳秒），取多轮中最快的一轮
+func measureNSPerOp(chain *core.Chain[int, int]) float64 {
+	ctx := context.Background()
+
+	// 预热
+	for i := 0; i < overheadIterations/10; i++ {
+		chain.Execute(ctx, i)
+	}
+
+	best := time.Duration(-1)
+	for round := 0; round < overheadRounds; round++ {
+		start := time.Now()
+		for i := 0; i < overheadIterations; i++ {
+			chain.Execute(ctx, i)
+		}
+		if elapsed := time.Since(start); best < 0 || elapsed < best {
+			best = elapsed
+		}
+	}
+
+	return float64(best) / overheadIterations
+}
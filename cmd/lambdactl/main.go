@@ -0,0 +1,20 @@
+// lambdactl 命令行模板
+// 本仓库没有内置的lambda包，直接构建得到的二进制中没有任何lambda：--list 为空，invoke 总是找不到。
+// 使用时复制本目录，并以空白导入引入通过 registry.RegisterAutoHandler 注册lambda的包，例如：
+//
+//	import _ "example.com/app/lambdas"
+package main
+
+import (
+	"os"
+
+	"github.com/ZHLX2005/minilambda/lambdactl"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+func main() {
+	// 执行空白导入的包中通过 registry.RegisterAutoHandler 注册的处理函数
+	registry.ExecuteAutoHandlers()
+
+	os.Exit(lambdactl.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,34 @@
+package contextx
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// User 当前请求的用户
+type User struct {
+	ID    int
+	Name  string
+	Roles []string
+}
+
+// HasRole 判断用户是否拥有指定角色
+func (u User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// UserKey 当前用户的 context 键
+// 使用带类型的键而不是字符串，避免不同包之间的键冲突
+var UserKey = core.NewContextKey[User]("user")
+
+// WithUser 返回携带当前用户的 context
+func WithUser(ctx context.Context, user User) context.Context {
+	return UserKey.WithValue(ctx, user)
+}
+
+// UserFromContext 从 context 中读取当前用户，未设置时返回 false
+func UserFromContext(ctx context.Context) (User, bool) {
+	return UserKey.Value(ctx)
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+var (
+	// ErrAsyncPoolClosed 工作池已关闭
+	ErrAsyncPoolClosed = errors.New("async pool closed")
+	// ErrAsyncQueueFull 工作池队列已满
+	ErrAsyncQueueFull = errors.New("async queue full")
+)
+
+// AsyncPool 有界异步工作池
+// 固定数量的 worker 从有界队列中取任务执行，任务中的 panic 会被恢复并记录日志
+type AsyncPool struct {
+	mu     sync.RWMutex
+	tasks  chan func()
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewAsyncPool 创建异步工作池，workers 和 queueSize 至少为 1
+func NewAsyncPool(workers, queueSize int) *AsyncPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	pool := &AsyncPool{tasks: make(chan func(), queueSize)}
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go pool.worker()
+	}
+	return pool
+}
+
+// worker 执行队列中的任务直到队列关闭
+func (p *AsyncPool) worker() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		p.run(task)
+	}
+}
+
+// run 执行单个任务并恢复 panic
+func (p *AsyncPool) run(task func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Async] panic recovered: %v", r)
+		}
+	}()
+	task()
+}
+
+// Submit 提交任务，不阻塞
+// 队列已满时返回 ErrAsyncQueueFull，工作池关闭后返回 ErrAsyncPoolClosed，任务均被丢弃
+func (p *AsyncPool) Submit(task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrAsyncPoolClosed
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		return ErrAsyncQueueFull
+	}
+}
+
+// Shutdown 停止接收新任务并等待已提交的任务执行完毕
+// ctx 先结束时返回 ctx 的错误，剩余任务仍会在后台继续执行
+func (p *AsyncPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	defaultAsyncPool     *AsyncPool
+	defaultAsyncPoolOnce sync.Once
+)
+
+// DefaultAsyncPool 返回 Async 中间件使用的默认工作池（4 个 worker，队列长度 1024）
+func DefaultAsyncPool() *AsyncPool {
+	defaultAsyncPoolOnce.Do(func() {
+		defaultAsyncPool = NewAsyncPool(4, 1024)
+	})
+	return defaultAsyncPool
+}
+
+// Async 异步副作用中间件
+// 同步调用 next 后把 fn 投递到默认工作池执行，调用方无需等待审计、指标上报等副作用完成。
+// fn 收到的 context 不会随请求取消；队列已满或工作池已关闭时副作用被丢弃并记录日志。
+func Async[I any, O any](fn func(ctx context.Context, input I, output O, err error)) Middleware[I, O] {
+	return AsyncWithPool[I, O](DefaultAsyncPool(), fn)
+}
+
+// AsyncWithPool 使用指定工作池的异步副作用中间件
+func AsyncWithPool[I any, O any](pool *AsyncPool, fn func(ctx context.Context, input I, output O, err error)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+
+		detached := context.WithoutCancel(ctx)
+		if submitErr := pool.Submit(func() { fn(detached, input, output, err) }); submitErr != nil {
+			log.Printf("[Async] side effect dropped: %v", submitErr)
+		}
+
+		return output, err
+	}
+}
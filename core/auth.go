@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Principal 通过认证的调用方身份
+type Principal struct {
+	ID     string
+	Roles  []string
+	Claims map[string]string
+}
+
+// HasRole 判断身份是否拥有指定角色
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// PrincipalKey 已认证身份的 context 键，由 Authenticate 中间件设置
+var PrincipalKey = NewContextKey[Principal]("principal")
+
+// PrincipalFromContext 从 context 中读取已认证的身份
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	return PrincipalKey.Value(ctx)
+}
+
+// AuthTokenKey 调用方凭据（如 Bearer token）的 context 键
+var AuthTokenKey = NewContextKey[string]("auth_token")
+
+// WithAuthToken 返回携带调用方凭据的 context
+func WithAuthToken(ctx context.Context, token string) context.Context {
+	return AuthTokenKey.WithValue(ctx, token)
+}
+
+// AuthTokenFromContext 从 context 中读取调用方凭据
+func AuthTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := AuthTokenKey.Value(ctx)
+	return token, ok && token != ""
+}
+
+// Authenticate 身份认证中间件
+// 调用 verifier 认证本次请求，成功时把返回的 Principal 注入 context（通过 PrincipalFromContext 读取）
+// 后调用 next；失败时不调用 next，返回包装了 ErrUnauthenticated 与 verifier 错误的错误。
+func Authenticate[I any, O any](verifier func(ctx context.Context) (Principal, error)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		principal, err := verifier(ctx)
+		if err != nil {
+			var zero O
+			return zero, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+		}
+
+		return next(PrincipalKey.WithValue(ctx, principal), input)
+	}
+}
+
+var (
+	// ErrMissingToken context 中没有调用方凭据
+	ErrMissingToken = errors.New("missing auth token")
+	// ErrInvalidToken 调用方凭据无效
+	ErrInvalidToken = errors.New("invalid auth token")
+)
+
+// TokenVerifier 基于静态令牌表的认证器，从 context 中读取 WithAuthToken 设置的凭据
+// 以恒定时间比较所有令牌，避免通过耗时推测令牌内容
+func TokenVerifier(tokens map[string]Principal) func(ctx context.Context) (Principal, error) {
+	return func(ctx context.Context) (Principal, error) {
+		token, ok := AuthTokenFromContext(ctx)
+		if !ok {
+			return Principal{}, ErrMissingToken
+		}
+
+		var matched Principal
+		found := false
+		for candidate, principal := range tokens {
+			if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+				matched = principal
+				found = true
+			}
+		}
+
+		if !found {
+			return Principal{}, ErrInvalidToken
+		}
+		return matched, nil
+	}
+}
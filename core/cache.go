@@ -0,0 +1,229 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// TTLCache 带过期时间的 LRU 缓存
+// 只缓存成功的结果，过期条目在访问或写入时惰性清理
+type TTLCache[I comparable, O any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[I]*list.Element
+	hits     int64
+	misses   int64
+}
+
+// ttlCacheEntry 缓存条目
+type ttlCacheEntry[I comparable, O any] struct {
+	key      I
+	output   O
+	expireAt time.Time
+}
+
+// NewTTLCache 创建 TTL + LRU 缓存
+// capacity <= 0 表示不限制容量，ttl <= 0 表示永不过期
+func NewTTLCache[I comparable, O any](capacity int, ttl time.Duration) *TTLCache[I, O] {
+	return &TTLCache[I, O]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[I]*list.Element),
+	}
+}
+
+// Get 获取缓存值
+func (c *TTLCache[I, O]) Get(key I) (O, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[key]; exists {
+		entry := elem.Value.(*ttlCacheEntry[I, O])
+		if c.ttl <= 0 || time.Now().Before(entry.expireAt) {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return entry.output, true
+		}
+		c.removeElement(elem)
+	}
+
+	c.misses++
+	var zero O
+	return zero, false
+}
+
+// Set 写入缓存值，超出容量时淘汰最久未使用的条目
+func (c *TTLCache[I, O]) Set(key I, output O) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expireAt := time.Now().Add(c.ttl)
+
+	if elem, exists := c.entries[key]; exists {
+		entry := elem.Value.(*ttlCacheEntry[I, O])
+		entry.output = output
+		entry.expireAt = expireAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&ttlCacheEntry[I, O]{
+		key:      key,
+		output:   output,
+		expireAt: expireAt,
+	})
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		c.evict()
+	}
+}
+
+// evict 淘汰一个条目，优先淘汰已过期的
+func (c *TTLCache[I, O]) evict() {
+	if c.ttl > 0 {
+		now := time.Now()
+		for elem := c.order.Back(); elem != nil; elem = elem.Prev() {
+			if !now.Before(elem.Value.(*ttlCacheEntry[I, O]).expireAt) {
+				c.removeElement(elem)
+				return
+			}
+		}
+	}
+
+	if oldest := c.order.Back(); oldest != nil {
+		c.removeElement(oldest)
+	}
+}
+
+// removeElement 删除条目（调用方需持有锁）
+func (c *TTLCache[I, O]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*ttlCacheEntry[I, O]).key)
+}
+
+// Hits 返回缓存命中次数
+func (c *TTLCache[I, O]) Hits() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// Misses 返回缓存未命中次数
+func (c *TTLCache[I, O]) Misses() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.misses
+}
+
+// Len 返回当前缓存条目数（可能包含尚未清理的过期条目）
+func (c *TTLCache[I, O]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Purge 清空缓存
+func (c *TTLCache[I, O]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[I]*list.Element)
+}
+
+// Middleware 返回缓存中间件
+func (c *TTLCache[I, O]) Middleware() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if cached, found := c.Get(input); found {
+			return cached, nil
+		}
+
+		output, err := next(ctx, input)
+		if err != nil {
+			return output, err
+		}
+
+		c.Set(input, output)
+		return output, nil
+	}
+}
+
+// NegativeResult 被缓存的否定结果（如“未找到”），Err 可以为 nil
+type NegativeResult[O any] struct {
+	Output O
+	Err    error
+}
+
+// NegativeCacheStore 否定结果存储
+type NegativeCacheStore[I comparable, O any] interface {
+	Get(key I) (NegativeResult[O], bool)
+	Set(key I, result NegativeResult[O], ttl time.Duration)
+}
+
+// MemoryNegativeCache 内存否定结果存储，过期条目在访问时惰性清理
+type MemoryNegativeCache[I comparable, O any] struct {
+	mu      sync.Mutex
+	entries map[I]negativeCacheEntry[O]
+}
+
+// negativeCacheEntry 否定结果条目
+type negativeCacheEntry[O any] struct {
+	result   NegativeResult[O]
+	expireAt time.Time
+}
+
+// NewMemoryNegativeCache 创建内存否定结果存储
+func NewMemoryNegativeCache[I comparable, O any]() *MemoryNegativeCache[I, O] {
+	return &MemoryNegativeCache[I, O]{
+		entries: make(map[I]negativeCacheEntry[O]),
+	}
+}
+
+// Get 获取未过期的否定结果
+func (c *MemoryNegativeCache[I, O]) Get(key I) (NegativeResult[O], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, exists := c.entries[key]; exists {
+		if entry.expireAt.IsZero() || time.Now().Before(entry.expireAt) {
+			return entry.result, true
+		}
+		delete(c.entries, key)
+	}
+
+	return NegativeResult[O]{}, false
+}
+
+// Set 保存否定结果，ttl <= 0 表示永不过期
+func (c *MemoryNegativeCache[I, O]) Set(key I, result NegativeResult[O], ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := negativeCacheEntry[O]{result: result}
+	if ttl > 0 {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+}
+
+// NegativeCache 否定结果缓存中间件
+// isNegative 判定为否定的结果（输出与错误）会缓存 ttl 时长，期间相同输入直接返回缓存的输出与错误，
+// 不再调用 next；其余结果照常返回且不缓存，适合经常“未找到”的高开销查询。
+func NegativeCache[I comparable, O any](cache NegativeCacheStore[I, O], isNegative func(O, error) bool, ttl time.Duration) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if cached, found := cache.Get(input); found {
+			return cached.Output, cached.Err
+		}
+
+		output, err := next(ctx, input)
+		if isNegative(output, err) {
+			cache.Set(input, NegativeResult[O]{Output: output, Err: err}, ttl)
+		}
+		return output, err
+	}
+}
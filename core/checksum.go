@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Checksum 返回负载的 SHA-256 校验和（小写十六进制），与 VerifyChecksum 使用的算法一致
+func Checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyChecksum 校验和验证中间件，使用 SHA-256
+// extract 从输入中取出负载与其携带的十六进制校验和，重新计算不一致时返回 ErrChecksumMismatch 且不调用 next
+func VerifyChecksum[I any, O any](extract func(I) (payload []byte, checksum string)) Middleware[I, O] {
+	return VerifyChecksumWith[I, O](sha256.New, extract)
+}
+
+// VerifyChecksumWith 使用指定哈希算法的校验和验证中间件
+func VerifyChecksumWith[I any, O any](newHash func() hash.Hash, extract func(I) (payload []byte, checksum string)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		payload, checksum := extract(input)
+
+		h := newHash()
+		h.Write(payload)
+		expected := hex.EncodeToString(h.Sum(nil))
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(checksum))) != 1 {
+			var zero O
+			return zero, fmt.Errorf("%w: expected %s, got %q", ErrChecksumMismatch, expected, checksum)
+		}
+
+		return next(ctx, input)
+	}
+}
@@ -0,0 +1,15 @@
+package core
+
+import (
+	"time"
+)
+
+// Since 返回自 start 以来经过的时间，保证不为负数
+// time.Now() 返回的时间带有单调时钟读数，调整系统时间不会影响差值；
+// 若 start 丢失了单调读数（例如经过序列化或 Round(0)），墙上时钟回拨时返回 0
+func Since(start time.Time) time.Duration {
+	if d := time.Since(start); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec 序列化编解码器
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// CodecError 编解码错误，与处理器返回的错误区分
+type CodecError struct {
+	Codec string // 编解码器名称
+	Op    string // 出错的阶段，如 "marshal input"
+	Err   error
+}
+
+// Error 实现 error 接口
+func (e *CodecError) Error() string {
+	return fmt.Sprintf("codec %s: %s: %v", e.Codec, e.Op, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+// JSONCodec JSON 编解码器
+type JSONCodec struct{}
+
+// Name 返回编解码器名称
+func (JSONCodec) Name() string { return "json" }
+
+// Marshal 编码为 JSON
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal 从 JSON 解码
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// GobCodec gob 编解码器
+type GobCodec struct{}
+
+// Name 返回编解码器名称
+func (GobCodec) Name() string { return "gob" }
+
+// Marshal 编码为 gob
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 从 gob 解码
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// GzipCodec 在内层编解码器之上做 gzip 压缩
+type GzipCodec struct {
+	Inner Codec
+}
+
+// NewGzipCodec 创建 gzip 压缩编解码器
+func NewGzipCodec(inner Codec) GzipCodec {
+	return GzipCodec{Inner: inner}
+}
+
+// Name 返回编解码器名称
+func (c GzipCodec) Name() string { return c.Inner.Name() + "+gzip" }
+
+// Marshal 编码后压缩
+func (c GzipCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.Inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return GzipCompress(data)
+}
+
+// Unmarshal 解压后解码
+func (c GzipCodec) Unmarshal(data []byte, v any) error {
+	raw, err := GzipDecompress(data)
+	if err != nil {
+		return err
+	}
+	return c.Inner.Unmarshal(raw, v)
+}
+
+// GzipCompress gzip 压缩数据
+func GzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GzipDecompress 解压 gzip 数据
+func GzipDecompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// roundTrip 经编解码器编码再解码
+func roundTrip[T any](codec Codec, value T, stage string) (T, error) {
+	var decoded T
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return decoded, &CodecError{Codec: codec.Name(), Op: "marshal " + stage, Err: err}
+	}
+	if err := codec.Unmarshal(data, &decoded); err != nil {
+		return decoded, &CodecError{Codec: codec.Name(), Op: "unmarshal " + stage, Err: err}
+	}
+	return decoded, nil
+}
+
+// WithCodec 编解码中间件
+// 输入在交给处理器前、输出在返回前都经 codec 编码再解码，模拟跨网络边界的序列化，
+// 使处理器拿到的是与调用方不共享内存的副本。编解码失败时返回 *CodecError，与处理器错误区分。
+func WithCodec[I any, O any](codec Codec) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		decodedInput, err := roundTrip(codec, input, "input")
+		if err != nil {
+			var zero O
+			return zero, err
+		}
+
+		output, err := next(ctx, decodedInput)
+		if err != nil {
+			return output, err
+		}
+
+		return roundTrip(codec, output, "output")
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// ContextKey 带类型的 context 键
+// 以指针身份区分，不同的键即使名称相同也不会冲突
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey 创建带类型的 context 键
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// WithValue 返回携带该键值的 context
+func (k *ContextKey[T]) WithValue(ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, k, value)
+}
+
+// Value 从 context 中读取该键的值
+func (k *ContextKey[T]) Value(ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(k).(T)
+	return value, ok
+}
+
+// String 返回键名称
+func (k *ContextKey[T]) String() string {
+	return k.name
+}
+
+// WithContextValue 上下文注入中间件
+// 以携带 key/value 的派生 context 调用 next
+func WithContextValue[I any, O any](key, value any) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		return next(context.WithValue(ctx, key, value), input)
+	}
+}
+
+// WithTypedContextValue 使用带类型键的上下文注入中间件
+func WithTypedContextValue[I any, O any, T any](key *ContextKey[T], value T) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		return next(key.WithValue(ctx, value), input)
+	}
+}
+
+// lambdaConfigKey lambda结构化配置的 context 键
+type lambdaConfigKey struct{}
+
+// withLambdaConfig 返回携带lambda配置的 context，配置为空时返回 ctx 本身
+func withLambdaConfig(ctx context.Context, config map[any]any) context.Context {
+	if len(config) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, lambdaConfigKey{}, config)
+}
+
+// ConfigFromContext 读取当前调用的lambda通过 WithConfig 附加的配置
+func ConfigFromContext(ctx context.Context, key any) (any, bool) {
+	config, _ := ctx.Value(lambdaConfigKey{}).(map[any]any)
+	value, ok := config[key]
+	return value, ok
+}
+
+// ConfigValue 以指定类型读取当前调用的lambda配置，类型不匹配时返回 false
+func ConfigValue[T any](ctx context.Context, key any) (T, bool) {
+	value, _ := ConfigFromContext(ctx, key)
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// stageContextKey 阶段 context 的键
+type stageContextKey struct{}
+
+// WithStageContext 返回携带阶段 context 的 context
+// 阶段 context 只约束某个中间件自身的工作（如缓存查询），不影响传给 next 的 context：
+// 中间件用 StageContext(ctx) 执行自己的工作，再以原 ctx 调用 next。
+func WithStageContext(ctx, stage context.Context) context.Context {
+	return context.WithValue(ctx, stageContextKey{}, stage)
+}
+
+// StageContext 返回当前阶段的 context，没有设置阶段 context 时返回 ctx 本身
+func StageContext(ctx context.Context) context.Context {
+	if stage, ok := ctx.Value(stageContextKey{}).(context.Context); ok && stage != nil {
+		return stage
+	}
+	return ctx
+}
+
+// EndStage 清除阶段 context，之后的中间件与处理器调用 StageContext 将得到 ctx 本身
+func EndStage(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stageContextKey{}).(context.Context); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stageContextKey{}, nil)
+}
+
+// StageTimeout 阶段超时中间件
+// 为其后的中间件提供一个 d 后超时的阶段 context（通过 StageContext 获取），
+// 只用于限制该中间件自身的工作；传给 next 的 ctx 保持原有的截止时间，处理器不受影响。
+//
+//	core.NewLambdaWithMiddleware("query", handler,
+//	    core.StageTimeout[Req, Resp](50*time.Millisecond),
+//	    cacheLookup, // 用 core.StageContext(ctx) 查询缓存，未命中时调用 next(core.EndStage(ctx), input)
+//	)
+func StageTimeout[I any, O any](d time.Duration) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		stage, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		return next(WithStageContext(ctx, stage), input)
+	}
+}
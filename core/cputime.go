@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// CPUTimeMetadataKey CPUTime 中间件在结果元数据中使用的键
+const CPUTimeMetadataKey = "cpu_time"
+
+// CPUTime CPU 时间统计中间件
+// 处理器执行期间把 goroutine 锁定在当前线程上，以线程级 CPU 时间（用户态+内核态）统计消耗；
+// 处理器自己启动的其它 goroutine 不计入。平台不支持线程级统计时退化为墙上时间。
+// 结果通过 sink 上报（name 为lambda名称），并记录到结果元数据的 CPUTimeMetadataKey 下。
+func CPUTime[I any, O any](sink func(name string, cpu time.Duration)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		wallStart := time.Now()
+		cpuStart, supported := threadCPUTime()
+
+		output, err := next(ctx, input)
+
+		cpu := Since(wallStart)
+		if supported {
+			if cpuEnd, ok := threadCPUTime(); ok && cpuEnd >= cpuStart {
+				cpu = cpuEnd - cpuStart
+			}
+		}
+
+		SetResultMetadata(ctx, CPUTimeMetadataKey, cpu)
+		if sink != nil {
+			name, _ := LambdaNameKey.Value(ctx)
+			sink(name, cpu)
+		}
+
+		return output, err
+	}
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"syscall"
+	"time"
+)
+
+// rusageThread 即 RUSAGE_THREAD，标准库 syscall 未导出该常量
+const rusageThread = 1
+
+// threadCPUTime 返回当前线程累计的 CPU 时间
+func threadCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(rusageThread, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package core
+
+import "time"
+
+// threadCPUTime 当前平台不支持线程级 CPU 时间
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
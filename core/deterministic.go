@@ -0,0 +1,26 @@
+package core
+
+import "sync/atomic"
+
+var deterministicMode atomic.Bool
+
+// SetDeterministicMode 开启或关闭确定性模式（用于测试）
+// 开启后，调用器的异步、批量与多路调用路径会在调用方 goroutine 中按固定顺序串行执行，
+// 使并发相关的测试可复现；关闭时行为不变。
+func SetDeterministicMode(enabled bool) {
+	deterministicMode.Store(enabled)
+}
+
+// DeterministicMode 返回是否处于确定性模式
+func DeterministicMode() bool {
+	return deterministicMode.Load()
+}
+
+// Go 启动并发任务；确定性模式下在当前 goroutine 中同步执行 fn
+func Go(fn func()) {
+	if deterministicMode.Load() {
+		fn()
+		return
+	}
+	go fn()
+}
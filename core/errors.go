@@ -0,0 +1,18 @@
+package core
+
+import "errors"
+
+var (
+	// ErrLambdaNotFound 按名称未找到lambda
+	ErrLambdaNotFound = errors.New("lambda not found")
+	// ErrTimeout 调用超时，同时包装 context.DeadlineExceeded
+	ErrTimeout = errors.New("timeout")
+	// ErrRateLimited 调用被限流拒绝
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrLoopDetected 同一请求中lambda被重复进入的次数超过上限
+	ErrLoopDetected = errors.New("invocation loop detected")
+	// ErrUnauthenticated 请求未通过身份认证
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrChecksumMismatch 输入的校验和与负载不一致
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
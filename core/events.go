@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// EventsMetadataKey CollectEvents 中间件在结果元数据中使用的键
+const EventsMetadataKey = "events"
+
+// EventEmitter 处理器执行期间发出的中间事件（如进度更新）的接收器
+// 事件按发出顺序保存，可选的 onEvent 回调在每次 Emit 时同步调用
+type EventEmitter struct {
+	mu      sync.Mutex
+	events  []any
+	onEvent func(event any)
+}
+
+// NewEventEmitter 创建事件接收器，onEvent 可以为 nil
+func NewEventEmitter(onEvent func(event any)) *EventEmitter {
+	return &EventEmitter{onEvent: onEvent}
+}
+
+// Emit 记录一个事件
+func (e *EventEmitter) Emit(event any) {
+	e.mu.Lock()
+	e.events = append(e.events, event)
+	e.mu.Unlock()
+
+	if e.onEvent != nil {
+		e.onEvent(event)
+	}
+}
+
+// Events 返回已记录事件的副本
+func (e *EventEmitter) Events() []any {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]any(nil), e.events...)
+}
+
+type eventEmitterKey struct{}
+
+// WithEventEmitter 返回绑定了事件接收器的 context
+func WithEventEmitter(ctx context.Context, emitter *EventEmitter) context.Context {
+	return context.WithValue(ctx, eventEmitterKey{}, emitter)
+}
+
+// EventEmitterFromContext 获取 context 绑定的事件接收器
+func EventEmitterFromContext(ctx context.Context) (*EventEmitter, bool) {
+	emitter, ok := ctx.Value(eventEmitterKey{}).(*EventEmitter)
+	return emitter, ok && emitter != nil
+}
+
+// Emit 向 context 绑定的事件接收器发出事件，没有绑定接收器时丢弃事件并返回 false
+func Emit(ctx context.Context, event any) bool {
+	emitter, ok := EventEmitterFromContext(ctx)
+	if !ok {
+		return false
+	}
+	emitter.Emit(event)
+	return true
+}
+
+// CollectEvents 事件收集中间件
+// 为本次调用绑定新的事件接收器，处理器通过 Emit 发出的事件在调用结束后（无论成功与否）
+// 以 []any 记录到结果元数据的 EventsMetadataKey 下；没有事件时不记录。
+func CollectEvents[I any, O any]() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		emitter := NewEventEmitter(nil)
+
+		output, err := next(WithEventEmitter(ctx, emitter), input)
+
+		if events := emitter.Events(); len(events) > 0 {
+			SetResultMetadata(ctx, EventsMetadataKey, events)
+		}
+		return output, err
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RequestIDKey 请求ID的 context 键
+var RequestIDKey = NewContextKey[string]("request_id")
+
+// WithRequestID 返回携带请求ID的 context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return RequestIDKey.WithValue(ctx, id)
+}
+
+// RequestIDFromContext 从 context 中读取请求ID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := RequestIDKey.Value(ctx)
+	return id, ok && id != ""
+}
+
+// NewRequestID 生成随机请求ID（32 位十六进制）
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// EnsureRequestID 返回携带请求ID的 context 与该ID
+// context 中已有请求ID时沿用，否则生成新的ID
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return ctx, id
+	}
+	id := NewRequestID()
+	return WithRequestID(ctx, id), id
+}
+
+// IdempotencyStore 幂等结果存储
+type IdempotencyStore[O any] interface {
+	Get(id string) (O, bool)
+	Set(id string, output O, ttl time.Duration)
+}
+
+// MemoryIdempotencyStore 内存幂等结果存储，过期条目在访问时惰性清理
+type MemoryIdempotencyStore[O any] struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry[O]
+}
+
+// idempotencyEntry 幂等结果条目
+type idempotencyEntry[O any] struct {
+	output   O
+	expireAt time.Time
+}
+
+// NewMemoryIdempotencyStore 创建内存幂等结果存储
+func NewMemoryIdempotencyStore[O any]() *MemoryIdempotencyStore[O] {
+	return &MemoryIdempotencyStore[O]{
+		entries: make(map[string]idempotencyEntry[O]),
+	}
+}
+
+// Get 获取未过期的结果
+func (s *MemoryIdempotencyStore[O]) Get(id string) (O, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[id]; exists {
+		if entry.expireAt.IsZero() || time.Now().Before(entry.expireAt) {
+			return entry.output, true
+		}
+		delete(s.entries, id)
+	}
+
+	var zero O
+	return zero, false
+}
+
+// Set 保存结果，ttl <= 0 表示永不过期
+func (s *MemoryIdempotencyStore[O]) Set(id string, output O, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := idempotencyEntry[O]{output: output}
+	if ttl > 0 {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+	s.entries[id] = entry
+}
+
+// IdempotentResult 幂等结果中间件
+// 从 context 读取请求ID（见 WithRequestID），同一请求ID的首个成功结果会被保存 ttl 时长，
+// 客户端重试整个请求时直接返回已保存的结果而不再执行处理器。
+// 存储键由lambda名称（LambdaNameKey）与请求ID组成，共享同一存储的多个lambda在同一请求中互不影响。
+// 同一键的并发调用只执行一次处理器，其余调用等待并共享其结果（包括错误）；
+// 失败的结果不会被保存，未携带请求ID的调用直接透传。
+func IdempotentResult[I any, O any](store IdempotencyStore[O], ttl time.Duration) Middleware[I, O] {
+	var mu sync.Mutex
+	calls := make(map[string]*singleflightCall[O])
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		id, ok := RequestIDFromContext(ctx)
+		if !ok {
+			return next(ctx, input)
+		}
+		name, _ := LambdaNameKey.Value(ctx)
+		key := name + "\x00" + id
+
+		if output, found := store.Get(key); found {
+			return output, nil
+		}
+
+		mu.Lock()
+		if call, exists := calls[key]; exists {
+			mu.Unlock()
+
+			select {
+			case <-call.done:
+				return call.output, call.err
+			case <-ctx.Done():
+				var zero O
+				return zero, ctx.Err()
+			}
+		}
+		call := &singleflightCall[O]{done: make(chan struct{})}
+		calls[key] = call
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			delete(calls, key)
+			mu.Unlock()
+			close(call.done)
+		}()
+
+		// 处理器panic时，等待者会收到此错误
+		call.err = fmt.Errorf("idempotent call did not complete")
+		call.output, call.err = next(ctx, input)
+		if call.err == nil {
+			store.Set(key, call.output, ttl)
+		}
+		return call.output, call.err
+	}
+}
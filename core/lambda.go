@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -29,74 +30,193 @@ func (l *Lambda[I, O]) Invoke(ctx context.Context, input I) (*LambdaResult[O], e
 		Timestamp: start,
 	}
 
+	ctx = withLambdaConfig(ctx, l.options.Config)
+
+	for _, hook := range l.options.OnStart {
+		hook(ctx)
+	}
+
+	// context 已取消或超时，不再执行处理器
+	if err := ctx.Err(); err != nil {
+		result.Duration = Since(start)
+		result.Error = err
+
+		if l.options.EnableMetrics {
+			l.updateMetrics(result.Duration, 0, err)
+		}
+		l.runCompletionHooks(ctx, result.Duration, err)
+
+		return result, err
+	}
+
 	// 如果设置了超时，创建带超时的context
+	parent := ctx
 	if l.options.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, l.options.Timeout)
 		defer cancel()
 	}
 
-	// 执行lambda函数
-	output, err := l.invokeWithRetry(ctx, input)
+	// 执行lambda函数；只有自身的超时触发（调用方的 context 仍有效）时才包装为 ErrTimeout
+	output, attempts, err := l.invokeWithLimit(ctx, input)
+	if err != nil && l.options.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		err = fmt.Errorf("%w after %v: %w", ErrTimeout, l.options.Timeout, err)
+	}
 
-	result.Duration = time.Since(start)
+	result.Duration = Since(start)
 	result.Output = output
 	result.Error = err
+	result.Attempts = attempts
 
 	// 更新指标
 	if l.options.EnableMetrics {
-		l.updateMetrics(result.Duration, err)
+		l.updateMetrics(result.Duration, attempts, err)
 	}
+	l.runCompletionHooks(ctx, result.Duration, err)
 
 	return result, err
 }
 
-// invokeWithRetry 带重试的lambda调用
-func (l *Lambda[I, O]) invokeWithRetry(ctx context.Context, input I) (O, error) {
+// InvokeWithOptions 以本次调用专用的选项调用lambda
+// 在lambda选项的副本上应用 opts（如 WithTimeout、WithRetries），不修改lambda保存的选项，
+// 指标仍累计到lambda自身的指标上。
+func (l *Lambda[I, O]) InvokeWithOptions(ctx context.Context, input I, opts ...LambdaOption) (*LambdaResult[O], error) {
+	if len(opts) == 0 {
+		return l.Invoke(ctx, input)
+	}
+	return l.WithOptions(opts...).Invoke(ctx, input)
+}
+
+// runCompletionHooks 按结果调用成功或失败钩子
+func (l *Lambda[I, O]) runCompletionHooks(ctx context.Context, duration time.Duration, err error) {
+	if err != nil {
+		for _, hook := range l.options.OnError {
+			hook(ctx, err, duration)
+		}
+		return
+	}
+
+	for _, hook := range l.options.OnSuccess {
+		hook(ctx, duration)
+	}
+}
+
+// invokeWithLimit 获取进程级执行许可后调用，返回输出、处理器调用次数与错误
+func (l *Lambda[I, O]) invokeWithLimit(ctx context.Context, input I) (O, int, error) {
+	release, err := acquireGlobal(ctx)
+	if err != nil {
+		var zero O
+		return zero, 0, err
+	}
+	defer release()
+
+	return l.invokeWithRetry(ctx, input)
+}
+
+// invokeWithRetry 带重试的lambda调用，返回输出、处理器调用次数与错误
+func (l *Lambda[I, O]) invokeWithRetry(ctx context.Context, input I) (O, int, error) {
 	var lastErr error
 	var zero O
 
+	attempts := 0
 	for attempt := 0; attempt <= l.options.Retries; attempt++ {
 		if attempt > 0 {
 			// 简单的重试延迟
 			select {
 			case <-ctx.Done():
-				return zero, ctx.Err()
+				return zero, attempts, ctx.Err()
 			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
 			}
 		}
 
-		output, err := l.invoke(ctx, input)
+		attempts++
+		output, err := l.callHandler(ctx, input)
 		if err == nil {
-			return output, nil
+			return output, attempts, nil
 		}
 
 		lastErr = err
 
 		// 如果是context错误，不重试
 		if ctx.Err() != nil {
-			return zero, ctx.Err()
+			return zero, attempts, ctx.Err()
 		}
 	}
 
-	return zero, lastErr
+	return zero, attempts, lastErr
+}
+
+// callHandler 调用处理函数，启用 Recover 时将panic转换为错误
+func (l *Lambda[I, O]) callHandler(ctx context.Context, input I) (output O, err error) {
+	if l.options.IsolatedExecution {
+		return l.callIsolated(ctx, input)
+	}
+
+	if l.options.Recover {
+		defer func() {
+			if r := recover(); r != nil {
+				var zero O
+				output = zero
+				err = fmt.Errorf("panic recovered: %v", r)
+			}
+		}()
+	}
+
+	return l.invoke(ctx, input)
+}
+
+// callIsolated 在独立的goroutine中调用处理函数并等待结果，panic 不会传播到调用方
+func (l *Lambda[I, O]) callIsolated(ctx context.Context, input I) (O, error) {
+	type handlerResult struct {
+		output O
+		err    error
+	}
+
+	done := make(chan handlerResult, 1)
+	go func() {
+		var res handlerResult
+		defer func() {
+			if r := recover(); r != nil {
+				res.err = fmt.Errorf("panic recovered: %v", r)
+			}
+			done <- res
+		}()
+
+		res.output, res.err = l.invoke(ctx, input)
+	}()
+
+	res := <-done
+	if res.err != nil {
+		var zero O
+		return zero, res.err
+	}
+	return res.output, nil
 }
 
 // updateMetrics 更新指标
-func (l *Lambda[I, O]) updateMetrics(duration time.Duration, err error) {
+func (l *Lambda[I, O]) updateMetrics(duration time.Duration, attempts int, err error) {
 	l.metrics.mu.Lock()
 	defer l.metrics.mu.Unlock()
 
-	l.metrics.TotalInvocations++
-	l.metrics.TotalDuration += duration
-	l.metrics.AverageDuration = l.metrics.TotalDuration / time.Duration(l.metrics.TotalInvocations)
-	l.metrics.LastInvocationTime = time.Now()
+	l.metrics.record(duration, err)
+	l.metrics.recordRetries(attempts)
+	l.metrics.publish()
+}
 
-	if err != nil {
-		l.metrics.ErrorInvocations++
-	} else {
-		l.metrics.SuccessInvocations++
-	}
+// Percentile 返回最近调用（最多 1024 次）耗时的 p 分位数（0 < p <= 100）与样本数
+// 只统计启用指标收集后的调用，没有样本时返回 0, 0
+func (l *Lambda[I, O]) Percentile(p float64) (time.Duration, int) {
+	l.metrics.mu.RLock()
+	defer l.metrics.mu.RUnlock()
+
+	return l.metrics.percentile(p)
+}
+
+// Subscribe 订阅指标变化，每次调用更新指标后推送一份快照
+// 推送是合并的：订阅者来不及读取时只保留最新的快照。调用返回的取消函数退订并关闭通道。
+// 未启用指标收集时不会推送。
+func (l *Lambda[I, O]) Subscribe() (<-chan MetricsSnapshot, func()) {
+	return l.metrics.subscribe()
 }
 
 // GetMetrics 获取指标
@@ -105,16 +225,56 @@ func (l *Lambda[I, O]) GetMetrics() LambdaMetrics {
 	defer l.metrics.mu.RUnlock()
 
 	// 返回副本
-	return LambdaMetrics{
-		TotalInvocations:   l.metrics.TotalInvocations,
-		SuccessInvocations: l.metrics.SuccessInvocations,
-		ErrorInvocations:   l.metrics.ErrorInvocations,
-		TotalDuration:      l.metrics.TotalDuration,
-		AverageDuration:    l.metrics.AverageDuration,
-		LastInvocationTime: l.metrics.LastInvocationTime,
+	return l.metrics.snapshot()
+}
+
+// MergeMetrics 将另一份指标的计数累加到当前指标，用于恢复持久化的指标
+func (l *Lambda[I, O]) MergeMetrics(other *LambdaMetrics) {
+	l.metrics.mu.Lock()
+	defer l.metrics.mu.Unlock()
+
+	l.metrics.TotalInvocations += other.TotalInvocations
+	l.metrics.SuccessInvocations += other.SuccessInvocations
+	l.metrics.ErrorInvocations += other.ErrorInvocations
+	l.metrics.TimeoutInvocations += other.TimeoutInvocations
+	l.metrics.RetryInvocations += other.RetryInvocations
+	l.metrics.TotalRetries += other.TotalRetries
+	l.metrics.TotalDuration += other.TotalDuration
+	l.metrics.SumSquares += other.SumSquares
+	l.metrics.refreshDerived()
+	if other.LastInvocationTime.After(l.metrics.LastInvocationTime) {
+		l.metrics.LastInvocationTime = other.LastInvocationTime
 	}
 }
 
+// ResetMetrics 清空指标
+func (l *Lambda[I, O]) ResetMetrics() {
+	l.metrics.mu.Lock()
+	defer l.metrics.mu.Unlock()
+
+	l.metrics.TotalInvocations = 0
+	l.metrics.SuccessInvocations = 0
+	l.metrics.ErrorInvocations = 0
+	l.metrics.TimeoutInvocations = 0
+	l.metrics.RetryInvocations = 0
+	l.metrics.TotalRetries = 0
+	l.metrics.TotalDuration = 0
+	l.metrics.SumSquares = 0
+	l.metrics.recent = nil
+	l.metrics.recentNext = 0
+	l.metrics.LastInvocationTime = time.Time{}
+	l.metrics.refreshDerived()
+}
+
+// Config 读取通过 WithConfig 附加的配置
+func (l *Lambda[I, O]) Config(key any) (any, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	value, ok := l.options.Config[key]
+	return value, ok
+}
+
 // GetName 获取lambda名称
 func (l *Lambda[I, O]) GetName() string {
 	return l.name
@@ -146,6 +306,7 @@ func (l *Lambda[I, O]) GetMeta() LambdaMeta {
 		InputType:     inputType,
 		OutputType:    outputType,
 		ComponentType: l.options.ComponentType,
+		Tags:          append([]string(nil), l.options.Tags...),
 		RegisteredAt:  time.Now(),
 	}
 }
@@ -169,6 +330,24 @@ func (l *Lambda[I, O]) WithOptions(opts ...LambdaOption) *Lambda[I, O] {
 	}
 }
 
+// Partial 绑定Pair输入的第一个元素，返回只接收第二个元素的lambda
+// 新lambda沿用原lambda的名称和选项，但拥有独立的指标
+func Partial[A any, B any, O any](l *Lambda[Pair[A, B], O], fixed A) *Lambda[B, O] {
+	l.mu.RLock()
+	newOptions := *l.options
+	l.mu.RUnlock()
+
+	invoke := l.invoke
+	return &Lambda[B, O]{
+		name: l.name,
+		invoke: func(ctx context.Context, input B) (O, error) {
+			return invoke(ctx, Pair[A, B]{First: fixed, Second: input})
+		},
+		options: &newOptions,
+		metrics: &LambdaMetrics{},
+	}
+}
+
 // String 返回lambda的字符串表示
 func (l *Lambda[I, O]) String() string {
 	return fmt.Sprintf("Lambda[%s]: %s -> %s", l.name, l.GetMeta().InputType, l.GetMeta().OutputType)
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// globalSemaphore 进程级并发信号量，为 nil 表示不限制
+var globalSemaphore atomic.Pointer[chan struct{}]
+
+// SetGlobalConcurrencyLimit 设置所有lambda同时执行数的进程级上限，n <= 0 表示不限制
+// 修改上限不影响已在执行中的调用；lambda 内部同步调用其他lambda时，上限过小可能导致死锁
+func SetGlobalConcurrencyLimit(n int) {
+	if n <= 0 {
+		globalSemaphore.Store(nil)
+		return
+	}
+
+	semaphore := make(chan struct{}, n)
+	globalSemaphore.Store(&semaphore)
+}
+
+// acquireGlobal 获取进程级执行许可，返回释放函数
+func acquireGlobal(ctx context.Context) (func(), error) {
+	semaphore := globalSemaphore.Load()
+	if semaphore == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case *semaphore <- struct{}{}:
+		return func() { <-*semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// namedLocks 进程级命名锁，按名称索引的容量为 1 的信号量
+var namedLocks sync.Map
+
+// namedLock 获取指定名称的锁，不存在时创建
+func namedLock(name string) chan struct{} {
+	if lock, ok := namedLocks.Load(name); ok {
+		return lock.(chan struct{})
+	}
+	lock, _ := namedLocks.LoadOrStore(name, make(chan struct{}, 1))
+	return lock.(chan struct{})
+}
+
+// LockNamed 获取进程级命名锁，返回释放函数；等待期间 ctx 结束时返回 ctx.Err()
+// 同名的锁在所有lambda与调用方之间共享
+func LockNamed(ctx context.Context, name string) (func(), error) {
+	lock := namedLock(name)
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NamedLock 命名锁中间件
+// 处理器执行期间持有名为 lockName 的进程级锁，使用同一名称的不同lambda不会同时执行，
+// 用于串行访问共享的外部资源。锁不可重入：持锁的处理器同步调用使用同一锁的lambda会死锁。
+func NamedLock[I any, O any](lockName string) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		release, err := LockNamed(ctx, lockName)
+		if err != nil {
+			var zero O
+			return zero, err
+		}
+		defer release()
+
+		return next(ctx, input)
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// LambdaNameKey 当前执行的lambda名称的 context 键，由 LambdaWithMiddleware 设置
+var LambdaNameKey = NewContextKey[string]("lambda_name")
+
+// resultMetadata 单次调用的结果元数据收集器
+type resultMetadata struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+type resultMetadataKey struct{}
+
+// withResultMetadata 返回携带新收集器的 context
+func withResultMetadata(ctx context.Context) (context.Context, *resultMetadata) {
+	metadata := &resultMetadata{}
+	return context.WithValue(ctx, resultMetadataKey{}, metadata), metadata
+}
+
+// snapshot 返回收集到的元数据副本，没有元数据时返回 nil
+func (m *resultMetadata) snapshot() map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.values) == 0 {
+		return nil
+	}
+	values := make(map[string]any, len(m.values))
+	for k, v := range m.values {
+		values[k] = v
+	}
+	return values
+}
+
+// SetResultMetadata 在本次调用的结果中记录元数据（LambdaResult.Metadata）
+// 仅在 LambdaWithMiddleware 的调用链内有效，否则返回 false
+func SetResultMetadata(ctx context.Context, key string, value any) bool {
+	metadata, ok := ctx.Value(resultMetadataKey{}).(*resultMetadata)
+	if !ok {
+		return false
+	}
+
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+
+	if metadata.values == nil {
+		metadata.values = make(map[string]any)
+	}
+	metadata.values[key] = value
+	return true
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MetricsSnapshot 某一时刻的指标快照，由 Subscribe 推送
+// 持久化与恢复指标请使用 registry.MetricsSnapshot。
+type MetricsSnapshot struct {
+	TotalInvocations   int64
+	SuccessInvocations int64
+	ErrorInvocations   int64
+	TimeoutInvocations int64
+	RetryInvocations   int64
+	TotalRetries       int64
+	TotalDuration      time.Duration
+	AverageDuration    time.Duration
+	StdDevDuration     time.Duration
+	LastInvocationTime time.Time
+}
+
+// latencyWindow 计算分位数时保留的最近调用耗时数量
+const latencyWindow = 1024
+
+// isTimeoutError 判断错误是否由超时引起（ErrTimeout 或 context.DeadlineExceeded）
+func isTimeoutError(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// record 记录一次调用，调用方需持有写锁
+// 超时同时计入 ErrorInvocations 与 TimeoutInvocations
+func (m *LambdaMetrics) record(duration time.Duration, err error) {
+	m.TotalInvocations++
+	m.TotalDuration += duration
+	m.SumSquares += float64(duration) * float64(duration)
+	m.LastInvocationTime = time.Now()
+	m.observeLatency(duration)
+
+	if err != nil {
+		m.ErrorInvocations++
+		if isTimeoutError(err) {
+			m.TimeoutInvocations++
+		}
+	} else {
+		m.SuccessInvocations++
+	}
+
+	m.refreshDerived()
+}
+
+// recordRetries 记录一次调用中的重试次数，调用方需持有写锁
+func (m *LambdaMetrics) recordRetries(attempts int) {
+	if attempts > 1 {
+		m.RetryInvocations++
+		m.TotalRetries += int64(attempts - 1)
+	}
+}
+
+// refreshDerived 由累计值重新计算平均耗时与标准差，调用方需持有写锁
+// 以浮点数计算后四舍五入，避免 Duration 整数除法的截断
+func (m *LambdaMetrics) refreshDerived() {
+	if m.TotalInvocations == 0 {
+		m.AverageDuration = 0
+		m.StdDevDuration = 0
+		return
+	}
+
+	n := float64(m.TotalInvocations)
+	mean := float64(m.TotalDuration) / n
+	m.AverageDuration = time.Duration(math.Round(mean))
+
+	// 总体方差 E[X²] - E[X]²，浮点误差可能使其略小于 0
+	variance := m.SumSquares/n - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	m.StdDevDuration = time.Duration(math.Round(math.Sqrt(variance)))
+}
+
+// snapshot 返回指标副本，调用方需持有读锁
+func (m *LambdaMetrics) snapshot() LambdaMetrics {
+	return LambdaMetrics{
+		TotalInvocations:   m.TotalInvocations,
+		SuccessInvocations: m.SuccessInvocations,
+		ErrorInvocations:   m.ErrorInvocations,
+		TimeoutInvocations: m.TimeoutInvocations,
+		RetryInvocations:   m.RetryInvocations,
+		TotalRetries:       m.TotalRetries,
+		TotalDuration:      m.TotalDuration,
+		AverageDuration:    m.AverageDuration,
+		SumSquares:         m.SumSquares,
+		StdDevDuration:     m.StdDevDuration,
+		LastInvocationTime: m.LastInvocationTime,
+	}
+}
+
+// subscribe 添加指标订阅者，返回只保留最新快照的通道与取消函数
+func (m *LambdaMetrics) subscribe() (<-chan MetricsSnapshot, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subscribers == nil {
+		m.subscribers = make(map[int]chan MetricsSnapshot)
+	}
+	id := m.nextSubscriber
+	m.nextSubscriber++
+
+	ch := make(chan MetricsSnapshot, 1)
+	m.subscribers[id] = ch
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			delete(m.subscribers, id)
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// publish 向订阅者推送当前快照，调用方需持有写锁
+// 通道中尚未被取走的旧快照会被替换，订阅者读取较慢时只会收到最新的快照
+func (m *LambdaMetrics) publish() {
+	if len(m.subscribers) == 0 {
+		return
+	}
+
+	snapshot := MetricsSnapshot{
+		TotalInvocations:   m.TotalInvocations,
+		SuccessInvocations: m.SuccessInvocations,
+		ErrorInvocations:   m.ErrorInvocations,
+		TimeoutInvocations: m.TimeoutInvocations,
+		RetryInvocations:   m.RetryInvocations,
+		TotalRetries:       m.TotalRetries,
+		TotalDuration:      m.TotalDuration,
+		AverageDuration:    m.AverageDuration,
+		StdDevDuration:     m.StdDevDuration,
+		LastInvocationTime: m.LastInvocationTime,
+	}
+
+	for _, ch := range m.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
+
+// observeLatency 把耗时写入最近调用的环形缓冲，调用方需持有写锁
+func (m *LambdaMetrics) observeLatency(duration time.Duration) {
+	if len(m.recent) < latencyWindow {
+		m.recent = append(m.recent, duration)
+		return
+	}
+	m.recent[m.recentNext] = duration
+	m.recentNext = (m.recentNext + 1) % latencyWindow
+}
+
+// percentile 返回最近调用耗时的 p 分位数（0 < p <= 100）与样本数，调用方需持有读锁
+func (m *LambdaMetrics) percentile(p float64) (time.Duration, int) {
+	n := len(m.recent)
+	if n == 0 {
+		return 0, 0
+	}
+
+	sorted := slices.Clone(m.recent)
+	slices.Sort(sorted)
+
+	// 最近秩法：取第 ceil(p/100*n) 个样本
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	rank = max(1, min(rank, n))
+	return sorted[rank-1], n
+}
@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"runtime"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -19,6 +23,8 @@ type Middleware[I any, O any] func(ctx context.Context, input I, next InvokeFunc
 type Chain[I any, O any] struct {
 	middlewares []Middleware[I, O]
 	final       InvokeFunc[I, O] // 最终的处理函数
+	trace       bool             // 是否记录中间件进出事件
+	traceNames  []string         // 中间件名称，用于追踪事件
 }
 
 // NewChain 创建新的中间件链
@@ -37,12 +43,32 @@ func (c *Chain[I, O]) Use(middlewares ...Middleware[I, O]) *Chain[I, O] {
 	return &Chain[I, O]{
 		middlewares: newMiddlewares,
 		final:       c.final,
+		trace:       c.trace,
+		traceNames:  c.traceNames,
+	}
+}
+
+// WithTrace 开启中间件进出事件追踪（返回新的链）
+// names 依次为各中间件命名，未命名的中间件使用 "middleware#<序号>"
+// 事件记录到通过 WithChainTrace 放入 context 的 ChainTrace 中
+func (c *Chain[I, O]) WithTrace(names ...string) *Chain[I, O] {
+	return &Chain[I, O]{
+		middlewares: c.middlewares,
+		final:       c.final,
+		trace:       true,
+		traceNames:  append([]string(nil), names...),
 	}
 }
 
 // Execute 执行中间件链
 // 按顺序执行中间件，每个中间件可以选择是否调用 next
 func (c *Chain[I, O]) Execute(ctx context.Context, input I) (O, error) {
+	// context 已取消或超时，不再执行
+	if err := ctx.Err(); err != nil {
+		var zero O
+		return zero, err
+	}
+
 	// 构建处理器链
 	handler := c.buildChain(0)
 
@@ -63,12 +89,88 @@ func (c *Chain[I, O]) buildChain(index int) InvokeFunc[I, O] {
 	// 下一个处理器
 	nextHandler := c.buildChain(index + 1)
 
+	if c.trace {
+		name := fmt.Sprintf("middleware#%d", index)
+		if index < len(c.traceNames) {
+			name = c.traceNames[index]
+		}
+
+		return func(ctx context.Context, input I) (O, error) {
+			trace, ok := ctx.Value(chainTraceKey{}).(*ChainTrace)
+			if !ok {
+				return currentMiddleware(ctx, input, nextHandler)
+			}
+
+			trace.record(name, TraceEnter)
+			defer trace.record(name, TraceExit)
+			return currentMiddleware(ctx, input, nextHandler)
+		}
+	}
+
 	// 返回包装后的处理器
 	return func(ctx context.Context, input I) (O, error) {
 		return currentMiddleware(ctx, input, nextHandler)
 	}
 }
 
+// TracePhase 追踪事件阶段
+type TracePhase int
+
+const (
+	TraceEnter TracePhase = iota
+	TraceExit
+)
+
+// String 返回阶段名称
+func (p TracePhase) String() string {
+	if p == TraceEnter {
+		return "enter"
+	}
+	return "exit"
+}
+
+// TraceEvent 中间件进出事件
+type TraceEvent struct {
+	Middleware string
+	Phase      TracePhase
+	Timestamp  time.Time
+}
+
+// ChainTrace 中间件链执行追踪记录
+type ChainTrace struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+// chainTraceKey ChainTrace 在 context 中的键
+type chainTraceKey struct{}
+
+// WithChainTrace 返回携带新 ChainTrace 的 context，执行后可通过 Events 读取事件
+func WithChainTrace(ctx context.Context) (context.Context, *ChainTrace) {
+	trace := &ChainTrace{}
+	return context.WithValue(ctx, chainTraceKey{}, trace), trace
+}
+
+// record 记录一个事件
+func (t *ChainTrace) record(name string, phase TracePhase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, TraceEvent{
+		Middleware: name,
+		Phase:      phase,
+		Timestamp:  time.Now(),
+	})
+}
+
+// Events 返回已记录事件的副本
+func (t *ChainTrace) Events() []TraceEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]TraceEvent(nil), t.events...)
+}
+
 // LambdaWithMiddleware 支持中间件的 Lambda
 type LambdaWithMiddleware[I any, O any] struct {
 	chain  *Chain[I, O]
@@ -95,11 +197,26 @@ func (l *LambdaWithMiddleware[I, O]) Invoke(ctx context.Context, input I) (*Lamb
 		Timestamp: start,
 	}
 
-	output, err := l.chain.Execute(ctx, input)
+	ctx = LambdaNameKey.WithValue(ctx, l.name)
+	ctx, metadata := withResultMetadata(ctx)
 
-	result.Duration = time.Since(start)
+	var output O
+	release, err := acquireGlobal(ctx)
+	executed := err == nil
+	if executed {
+		output, err = l.chain.Execute(ctx, input)
+		release()
+	}
+
+	result.Duration = Since(start)
 	result.Output = output
 	result.Error = err
+	result.Metadata = metadata.snapshot()
+	if attempts, ok := result.Metadata[RetryAttemptsMetadataKey].(int); ok {
+		result.Attempts = attempts
+	} else if executed {
+		result.Attempts = 1
+	}
 
 	return result, err
 }
@@ -124,14 +241,7 @@ func (l *LambdaWithMiddleware[I, O]) GetMetrics() LambdaMetrics {
 	l.metrics.mu.RLock()
 	defer l.metrics.mu.RUnlock()
 
-	return LambdaMetrics{
-		TotalInvocations:   l.metrics.TotalInvocations,
-		SuccessInvocations: l.metrics.SuccessInvocations,
-		ErrorInvocations:   l.metrics.ErrorInvocations,
-		TotalDuration:      l.metrics.TotalDuration,
-		AverageDuration:    l.metrics.AverageDuration,
-		LastInvocationTime: l.metrics.LastInvocationTime,
-	}
+	return l.metrics.snapshot()
 }
 
 // ============================================================
@@ -147,7 +257,7 @@ func Logger[I any, O any](name string) Middleware[I, O] {
 		// 调用下一个处理器
 		output, err := next(ctx, input)
 
-		duration := time.Since(start)
+		duration := Since(start)
 		if err != nil {
 			fmt.Printf("[%s] Completed with error in %v: %v\n", name, duration, err)
 		} else {
@@ -176,6 +286,11 @@ func Recovery[I any, O any]() Middleware[I, O] {
 }
 
 // Timeout 超时中间件
+// next 在单独的 goroutine 中执行，超时后立即返回 ErrTimeout 并取消传给 next 的 context。
+// Go 无法强制终止 goroutine（因此不存在真正的“硬超时”变体）：该 goroutine 会一直运行到
+// next 返回为止，处理器必须响应 ctx.Done() 才能及时退出，忽略 context 的阻塞处理器会占用
+// goroutine 直到自行结束。结果通道带缓冲，next 返回后 goroutine 不会因无人接收而阻塞；
+// next 中的 panic 会被恢复并丢弃，不会导致进程崩溃。
 func Timeout[I any, O any](timeout time.Duration) Middleware[I, O] {
 	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
 		ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -187,14 +302,20 @@ func Timeout[I any, O any](timeout time.Duration) Middleware[I, O] {
 		}, 1)
 
 		go func() {
-			output, err := next(ctx, input)
-			select {
-			case resultChan <- struct {
-				output O
-				err    error
-			}{output, err}:
-			case <-ctx.Done():
-			}
+			var output O
+			var err error
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic recovered: %v", r)
+				}
+				// 通道容量为 1 且只发送一次，不会阻塞
+				resultChan <- struct {
+					output O
+					err    error
+				}{output, err}
+			}()
+
+			output, err = next(ctx, input)
 		}()
 
 		select {
@@ -202,47 +323,85 @@ func Timeout[I any, O any](timeout time.Duration) Middleware[I, O] {
 			return res.output, res.err
 		case <-ctx.Done():
 			var zero O
-			return zero, fmt.Errorf("timeout after %v", timeout)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return zero, fmt.Errorf("%w after %v: %w", ErrTimeout, timeout, ctx.Err())
+			}
+			return zero, ctx.Err()
 		}
 	}
 }
 
+// RetryAttemptKey Retry 中间件传给 next 的当前尝试序号（从 1 开始）的 context 键
+var RetryAttemptKey = NewContextKey[int]("retry_attempt")
+
+// RetryAttemptsMetadataKey Retry 中间件在结果元数据中记录实际尝试次数使用的键
+const RetryAttemptsMetadataKey = "attempts"
+
 // Retry 重试中间件
+// 实际尝试次数记录到结果元数据的 RetryAttemptsMetadataKey 下，并由 LambdaWithMiddleware 填入 LambdaResult.Attempts
 func Retry[I any, O any](maxRetries int) Middleware[I, O] {
 	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
-		var lastErr error
-		var zero O
+		return retryInvoke(ctx, input, next, maxRetries)
+	}
+}
 
-		for attempt := 0; attempt <= maxRetries; attempt++ {
-			if attempt > 0 {
-				// 指数退避
-				backoff := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
-				if backoff > 5*time.Second {
-					backoff = 5 * time.Second
-				}
+// RetryPolicy 重试策略，可通过 WithConfig 附加到lambda上
+type RetryPolicy struct {
+	MaxRetries int
+}
 
-				select {
-				case <-time.After(backoff):
-				case <-ctx.Done():
-					return zero, ctx.Err()
-				}
-			}
+// RetryPolicyKey 重试策略的配置键
+type RetryPolicyKey struct{}
 
-			output, err := next(ctx, input)
-			if err == nil {
-				return output, nil
-			}
+// RetryFromConfig 从lambda配置中读取重试策略的重试中间件
+// 以 WithConfig(RetryPolicyKey{}, RetryPolicy{...}) 配置；没有配置时不重试
+func RetryFromConfig[I any, O any]() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		policy, _ := ConfigValue[RetryPolicy](ctx, RetryPolicyKey{})
+		return retryInvoke(ctx, input, next, policy.MaxRetries)
+	}
+}
 
-			lastErr = err
+// retryInvoke 以指数退避最多重试 maxRetries 次调用 next
+func retryInvoke[I any, O any](ctx context.Context, input I, next InvokeFunc[I, O], maxRetries int) (O, error) {
+	var lastErr error
+	var zero O
+
+	attempts := 0
+	defer func() {
+		SetResultMetadata(ctx, RetryAttemptsMetadataKey, attempts)
+	}()
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			// 指数退避
+			backoff := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+			if backoff > 5*time.Second {
+				backoff = 5 * time.Second
+			}
 
-			// 如果是 context 错误，不重试
-			if ctx.Err() != nil {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
 				return zero, ctx.Err()
 			}
 		}
 
-		return zero, fmt.Errorf("after %d retries: %w", maxRetries, lastErr)
+		attempts++
+		output, err := next(RetryAttemptKey.WithValue(ctx, attempts), input)
+		if err == nil {
+			return output, nil
+		}
+
+		lastErr = err
+
+		// 如果是 context 错误，不重试
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
 	}
+
+	return zero, fmt.Errorf("after %d retries: %w", maxRetries, lastErr)
 }
 
 // Metrics 指标收集中间件
@@ -252,20 +411,12 @@ func Metrics[I any, O any](metrics *LambdaMetrics) Middleware[I, O] {
 
 		output, err := next(ctx, input)
 
-		duration := time.Since(start)
+		duration := Since(start)
 
 		// 更新指标
 		metrics.mu.Lock()
-		metrics.TotalInvocations++
-		metrics.TotalDuration += duration
-		metrics.AverageDuration = metrics.TotalDuration / time.Duration(metrics.TotalInvocations)
-		metrics.LastInvocationTime = time.Now()
-
-		if err != nil {
-			metrics.ErrorInvocations++
-		} else {
-			metrics.SuccessInvocations++
-		}
+		metrics.record(duration, err)
+		metrics.publish()
 		metrics.mu.Unlock()
 
 		return output, err
@@ -346,6 +497,7 @@ const (
 )
 
 type CircuitBreaker[I comparable] struct {
+	mu           sync.Mutex
 	maxFailures  int
 	resetTimeout time.Duration
 	lastFailure  time.Time
@@ -365,16 +517,22 @@ func NewCircuitBreaker[I comparable](maxFailures int, resetTimeout time.Duration
 func (cb *CircuitBreaker[I]) Middleware() Middleware[I, any] {
 	return func(ctx context.Context, input I, next InvokeFunc[I, any]) (any, error) {
 		// 检查熔断器状态
+		cb.mu.Lock()
 		if cb.state == CircuitOpen {
 			if time.Since(cb.lastFailure) > cb.resetTimeout {
 				cb.state = CircuitHalfOpen
 			} else {
+				cb.mu.Unlock()
 				return nil, fmt.Errorf("circuit breaker is OPEN for input: %v", input)
 			}
 		}
+		cb.mu.Unlock()
 
 		output, err := next(ctx, input)
 
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+
 		// 记录失败
 		if err != nil {
 			cb.failures[input]++
@@ -397,8 +555,26 @@ func (cb *CircuitBreaker[I]) Middleware() Middleware[I, any] {
 	}
 }
 
+// State 返回熔断器当前状态
+func (cb *CircuitBreaker[I]) State() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Reset 手动关闭熔断器并清空失败计数，无需等待 resetTimeout
+func (cb *CircuitBreaker[I]) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = CircuitClosed
+	cb.failures = make(map[I]int)
+	cb.lastFailure = time.Time{}
+}
+
 // RateLimit 限流中间件（简单实现）
 type RateLimiter struct {
+	mu          sync.Mutex
 	maxRequests int
 	window      time.Duration
 	requests    []time.Time
@@ -413,6 +589,9 @@ func NewRateLimiter(maxRequests int, window time.Duration) *RateLimiter {
 }
 
 func (rl *RateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
 	now := time.Now()
 
 	// 清理过期的请求记录
@@ -434,11 +613,11 @@ func (rl *RateLimiter) Allow() bool {
 	return true
 }
 
-func RateLimit[I any, O any](limiter *RateLimiter) Middleware[I, O] {
+func RateLimit[I any, O any](limiter Limiter) Middleware[I, O] {
 	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
 		if !limiter.Allow() {
 			var zero O
-			return zero, fmt.Errorf("rate limit exceeded")
+			return zero, ErrRateLimited
 		}
 
 		return next(ctx, input)
@@ -460,7 +639,7 @@ func BeforeAfter[I any, O any](
 
 		// 调用下一个处理器
 		output, err := next(ctx, input)
-		duration := time.Since(start)
+		duration := Since(start)
 
 		// 执行后置逻辑
 		if after != nil {
@@ -470,3 +649,607 @@ func BeforeAfter[I any, O any](
 		return output, err
 	}
 }
+
+// DeadLetter 死信中间件
+// 处理器返回错误时，将输入和错误交给 sink 以便后续重放
+func DeadLetter[I any, O any](sink func(input I, err error)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+		if err != nil && sink != nil {
+			sink(input, err)
+		}
+
+		return output, err
+	}
+}
+
+// singleflightCall 一次进行中的调用
+type singleflightCall[O any] struct {
+	done   chan struct{}
+	output O
+	err    error
+}
+
+// Singleflight 合并相同输入的并发调用
+// 同一输入在执行期间只会运行一次处理器，所有调用者共享同一结果
+func Singleflight[I comparable, O any]() Middleware[I, O] {
+	var mu sync.Mutex
+	calls := make(map[I]*singleflightCall[O])
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		mu.Lock()
+		if call, exists := calls[input]; exists {
+			mu.Unlock()
+
+			select {
+			case <-call.done:
+				return call.output, call.err
+			case <-ctx.Done():
+				var zero O
+				return zero, ctx.Err()
+			}
+		}
+
+		call := &singleflightCall[O]{done: make(chan struct{})}
+		calls[input] = call
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			delete(calls, input)
+			mu.Unlock()
+			close(call.done)
+		}()
+
+		// 处理器panic时，等待者会收到此错误
+		call.err = fmt.Errorf("singleflight call did not complete")
+		call.output, call.err = next(ctx, input)
+		return call.output, call.err
+	}
+}
+
+// Experiment A/B 实验分桶中间件
+// 根据 key 的哈希值按权重确定性地将输入路由到某个变体
+// 命中的变体没有对应处理函数或权重总和为 0 时，调用 next
+func Experiment[I any, O any](key func(I) string, variants map[string]InvokeFunc[I, O], weights map[string]int) Middleware[I, O] {
+	// 按名称排序，保证分桶结果稳定
+	names := make([]string, 0, len(weights))
+	total := 0
+	for name, weight := range weights {
+		if weight > 0 {
+			names = append(names, name)
+			total += weight
+		}
+	}
+	sort.Strings(names)
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if total == 0 {
+			return next(ctx, input)
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(key(input)))
+		bucket := int(h.Sum32() % uint32(total))
+
+		for _, name := range names {
+			bucket -= weights[name]
+			if bucket < 0 {
+				if variant, exists := variants[name]; exists && variant != nil {
+					return variant(ctx, input)
+				}
+				break
+			}
+		}
+
+		return next(ctx, input)
+	}
+}
+
+// Fallback 降级中间件
+// 处理器返回错误时调用 fn，fn 可返回默认值恢复，也可原样返回错误
+func Fallback[I any, O any](fn func(ctx context.Context, input I, err error) (O, error)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+		if err == nil {
+			return output, nil
+		}
+
+		return fn(ctx, input, err)
+	}
+}
+
+// deferStackKey 清理函数栈在 context 中的键
+type deferStackKey struct{}
+
+// deferStack 清理函数栈
+type deferStack struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// Defer 向 context 中的清理栈注册清理函数
+// 需要配合 Finalize 中间件使用，未找到清理栈时返回 false
+func Defer(ctx context.Context, fn func()) bool {
+	stack, ok := ctx.Value(deferStackKey{}).(*deferStack)
+	if !ok {
+		return false
+	}
+
+	stack.mu.Lock()
+	stack.fns = append(stack.fns, fn)
+	stack.mu.Unlock()
+	return true
+}
+
+// Finalize 清理中间件
+// 在 next 完成后按 LIFO 顺序执行通过 Defer 注册的清理函数，出错或 panic 时同样执行
+func Finalize[I any, O any]() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		stack := &deferStack{}
+		ctx = context.WithValue(ctx, deferStackKey{}, stack)
+
+		defer func() {
+			stack.mu.Lock()
+			fns := stack.fns
+			stack.fns = nil
+			stack.mu.Unlock()
+
+			for i := len(fns) - 1; i >= 0; i-- {
+				fns[i]()
+			}
+		}()
+
+		return next(ctx, input)
+	}
+}
+
+// ErrBulkheadFull 舱壁已满且等待超时
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// Bulkhead 舱壁隔离中间件
+// 限制同时执行的调用数，超出时最多排队等待 queueTimeout，超时返回 ErrBulkheadFull。
+// maxConcurrent 小于 1 时按 1 处理。
+func Bulkhead[I any, O any](maxConcurrent int, queueTimeout time.Duration) Middleware[I, O] {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	semaphore := make(chan struct{}, maxConcurrent)
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		var zero O
+
+		select {
+		case semaphore <- struct{}{}:
+		default:
+			timer := time.NewTimer(queueTimeout)
+			defer timer.Stop()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-timer.C:
+				return zero, ErrBulkheadFull
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+		}
+		defer func() { <-semaphore }()
+
+		return next(ctx, input)
+	}
+}
+
+// DropDuplicates 事件去重中间件
+// window 时间内 id 相同的输入直接返回 dupResult，不调用处理器；处理失败的事件不计入去重
+func DropDuplicates[I any, O any](idFn func(I) string, window time.Duration, dupResult O) Middleware[I, O] {
+	var mu sync.Mutex
+	seen := make(map[string]time.Time)
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		id := idFn(input)
+		now := time.Now()
+
+		mu.Lock()
+		// 清理过期的记录
+		for key, t := range seen {
+			if now.Sub(t) >= window {
+				delete(seen, key)
+			}
+		}
+		if _, duplicate := seen[id]; duplicate {
+			mu.Unlock()
+			return dupResult, nil
+		}
+		seen[id] = now
+		mu.Unlock()
+
+		output, err := next(ctx, input)
+		if err != nil {
+			mu.Lock()
+			if seen[id].Equal(now) {
+				delete(seen, id)
+			}
+			mu.Unlock()
+		}
+
+		return output, err
+	}
+}
+
+// ConvertInput 输入单位转换中间件
+// 与 TransformInput 不同，conv 应为无损转换（如元转分），不会失败
+func ConvertInput[I any, O any](conv func(I) I) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		return next(ctx, conv(input))
+	}
+}
+
+// ConvertOutput 输出单位转换中间件
+// 通常与 ConvertInput 成对使用，把输出换算回调用方的单位；出错时原样返回
+func ConvertOutput[I any, O any](conv func(O) O) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+		if err != nil {
+			return output, err
+		}
+
+		return conv(output), nil
+	}
+}
+
+// Hedge 对冲请求中间件
+// 首次调用在 delay 内未返回时再发起一次调用，最多额外发起 maxHedges 次，
+// 返回最先成功的结果并通过派生 context 取消其余调用；对冲只由计时器触发，
+// 已发起的调用全部失败时返回最后一个错误，不会因失败而重试。
+// 同一输入可能被执行多次，只能用于幂等的处理器。maxHedges 小于 0 时按 0 处理。
+func Hedge[I any, O any](delay time.Duration, maxHedges int) Middleware[I, O] {
+	if maxHedges < 0 {
+		maxHedges = 0
+	}
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type attempt struct {
+			output O
+			err    error
+		}
+
+		attempts := make(chan attempt, maxHedges+1)
+		launch := func() {
+			go func() {
+				output, err := next(ctx, input)
+				attempts <- attempt{output: output, err: err}
+			}()
+		}
+
+		launch()
+		launched, outstanding := 1, 1
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		for {
+			select {
+			case a := <-attempts:
+				outstanding--
+				if a.err == nil {
+					return a.output, nil
+				}
+				if outstanding == 0 {
+					var zero O
+					return zero, a.err
+				}
+			case <-timer.C:
+				if launched <= maxHedges {
+					launch()
+					launched++
+					outstanding++
+					timer.Reset(delay)
+				}
+			case <-ctx.Done():
+				var zero O
+				return zero, ctx.Err()
+			}
+		}
+	}
+}
+
+// debounceBucket 一个输入值的防抖桶
+type debounceBucket[I any, O any] struct {
+	timer  *time.Timer
+	done   chan struct{}
+	ctx    context.Context
+	next   InvokeFunc[I, O]
+	output O
+	err    error
+}
+
+// Debounce 防抖中间件
+// 同一输入值的调用会被延迟 wait；窗口内到达的重复调用会重置计时并合并为一次处理器执行，
+// 所有等待者共享同一结果。与限流不同，防抖只推迟而不拒绝调用。
+// 不同输入值使用各自独立的防抖桶，互不影响；处理器使用最后一次调用的 context 执行。
+func Debounce[I comparable, O any](wait time.Duration) Middleware[I, O] {
+	var mu sync.Mutex
+	buckets := make(map[I]*debounceBucket[I, O])
+
+	fire := func(input I, bucket *debounceBucket[I, O]) {
+		mu.Lock()
+		delete(buckets, input)
+		ctx, next := bucket.ctx, bucket.next
+		mu.Unlock()
+
+		bucket.output, bucket.err = next(ctx, input)
+		close(bucket.done)
+	}
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		mu.Lock()
+		bucket, exists := buckets[input]
+		if exists {
+			bucket.ctx, bucket.next = ctx, next
+			// 计时器已触发时不再重置，直接等待本轮结果
+			if bucket.timer.Stop() {
+				bucket.timer.Reset(wait)
+			}
+		} else {
+			bucket = &debounceBucket[I, O]{
+				done: make(chan struct{}),
+				ctx:  ctx,
+				next: next,
+			}
+			buckets[input] = bucket
+			b := bucket
+			bucket.timer = time.AfterFunc(wait, func() { fire(input, b) })
+		}
+		mu.Unlock()
+
+		select {
+		case <-bucket.done:
+			return bucket.output, bucket.err
+		case <-ctx.Done():
+			var zero O
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// BatchEmitter 输出批量下发器，由 NewBatchEmitter 创建
+// 成功的输出会被累积，每满 n 个或距批次首个输出超过 interval 时整批交给 sink；
+// sink 在后台 goroutine 中按批次顺序依次被调用。关闭前应调用 Flush，否则缓冲中的输出会丢失。
+type BatchEmitter[I any, O any] struct {
+	n        int
+	interval time.Duration
+	sink     func([]O)
+
+	mu         sync.Mutex
+	drained    *sync.Cond
+	buffer     []O
+	generation uint64
+	timer      *time.Timer
+	queue      [][]O
+	draining   bool
+}
+
+// NewBatchEmitter 创建输出批量下发器
+// n <= 0 时仅按 interval 下发，interval <= 0 时仅按数量下发；两者都不大于 0 时 panic，
+// 否则缓冲会无限增长且 sink 永远不会被调用。
+func NewBatchEmitter[I any, O any](n int, interval time.Duration, sink func([]O)) *BatchEmitter[I, O] {
+	if n <= 0 && interval <= 0 {
+		panic("core: batch emitter needs a positive batch size or interval")
+	}
+	b := &BatchEmitter[I, O]{n: n, interval: interval, sink: sink}
+	b.drained = sync.NewCond(&b.mu)
+	return b
+}
+
+// drain 依次把排队的批次交给 sink
+func (b *BatchEmitter[I, O]) drain() {
+	for {
+		b.mu.Lock()
+		if len(b.queue) == 0 {
+			b.draining = false
+			b.drained.Broadcast()
+			b.mu.Unlock()
+			return
+		}
+		batch := b.queue[0]
+		b.queue = b.queue[1:]
+		b.mu.Unlock()
+
+		b.sink(batch)
+	}
+}
+
+// flushLocked 把当前缓冲排入队列，需在持有 mu 时调用
+func (b *BatchEmitter[I, O]) flushLocked() {
+	if len(b.buffer) == 0 {
+		return
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.generation++
+	b.queue = append(b.queue, b.buffer)
+	b.buffer = nil
+	if !b.draining {
+		b.draining = true
+		go b.drain()
+	}
+}
+
+// Flush 立即下发未满的批次，并等待所有已排队的批次交给 sink 后返回
+func (b *BatchEmitter[I, O]) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.flushLocked()
+	for b.draining {
+		b.drained.Wait()
+	}
+}
+
+// Middleware 返回累积输出的中间件
+// 每次调用仍立即返回自己的结果；与输入合并不同，处理器的调用次数不受影响。
+func (b *BatchEmitter[I, O]) Middleware() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+		if err != nil {
+			return output, err
+		}
+
+		b.mu.Lock()
+		b.buffer = append(b.buffer, output)
+		if b.n > 0 && len(b.buffer) >= b.n {
+			b.flushLocked()
+		} else if len(b.buffer) == 1 && b.interval > 0 {
+			gen := b.generation
+			b.timer = time.AfterFunc(b.interval, func() {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				if gen == b.generation {
+					b.flushLocked()
+				}
+			})
+		}
+		b.mu.Unlock()
+
+		return output, nil
+	}
+}
+
+// BatchEmit 输出批量下发中间件，参数含义同 NewBatchEmitter
+// 没有 Flush 入口，进程退出时缓冲中的输出会丢失；需要在关闭时下发剩余输出请使用 NewBatchEmitter。
+func BatchEmit[I any, O any](n int, interval time.Duration, sink func([]O)) Middleware[I, O] {
+	return NewBatchEmitter[I, O](n, interval, sink).Middleware()
+}
+
+// When 条件中间件
+// pred 返回 true 时才经过 mw，否则直接调用 next，除谓词外不引入额外开销
+func When[I any, O any](pred func(ctx context.Context, input I) bool, mw Middleware[I, O]) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if !pred(ctx, input) {
+			return next(ctx, input)
+		}
+		return mw(ctx, input, next)
+	}
+}
+
+// SLOGuard 成功率 SLO 监控中间件
+// 以最近 windowSize 次调用的滚动窗口统计成功率，窗口填满后成功率首次低于 minSuccessRate 时
+// 以当前成功率调用 onBreach；成功率恢复到阈值以上后才会再次触发，避免持续告警。
+// 中间件只做监控，不改变调用结果。
+func SLOGuard[I any, O any](windowSize int, minSuccessRate float64, onBreach func(rate float64)) Middleware[I, O] {
+	if windowSize <= 0 {
+		windowSize = 1
+	}
+
+	var mu sync.Mutex
+	outcomes := make([]bool, windowSize)
+	count, pos, successes := 0, 0, 0
+	breached := false
+
+	record := func(success bool) (float64, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if count == windowSize {
+			if outcomes[pos] {
+				successes--
+			}
+		} else {
+			count++
+		}
+		outcomes[pos] = success
+		if success {
+			successes++
+		}
+		pos = (pos + 1) % windowSize
+
+		if count < windowSize {
+			return 0, false
+		}
+
+		rate := float64(successes) / float64(windowSize)
+		if rate < minSuccessRate {
+			if !breached {
+				breached = true
+				return rate, true
+			}
+		} else {
+			breached = false
+		}
+		return rate, false
+	}
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+
+		if rate, fire := record(err == nil); fire && onBreach != nil {
+			onBreach(rate)
+		}
+
+		return output, err
+	}
+}
+
+// LogOnError 仅在失败时记录输入输出的中间件
+// next 返回错误时以输入、输出和错误调用 sink，成功的调用不产生任何日志
+func LogOnError[I any, O any](sink func(in I, out O, err error)) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		output, err := next(ctx, input)
+		if err != nil {
+			sink(input, output, err)
+		}
+		return output, err
+	}
+}
+
+// loopTracker 记录一次请求中各lambda当前的嵌套层数
+type loopTracker struct {
+	mu     sync.Mutex
+	visits map[string]int
+}
+
+// loopTrackerKey 循环检测记录在 context 中的键
+type loopTrackerKey struct{}
+
+// LoopGuard 调用循环检测中间件
+// 在 context 中记录本次请求当前嵌套进入的lambda名称（取自 LambdaNameKey）及层数，
+// 调用返回后层数减一，因此顺序多次调用同一lambda不受影响；
+// 同一名称嵌套超过 maxEntries 层时返回 ErrLoopDetected 而不再调用 next，
+// 用于打断 Retry、Fallback 等组合不当造成的 A→B→A 无限调用。
+// 嵌套调用需传递收到的 ctx 才能共享记录；没有名称的调用（如普通 Lambda）不做检测。
+func LoopGuard[I any, O any](maxEntries int) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		name, _ := LambdaNameKey.Value(ctx)
+		if name == "" {
+			return next(ctx, input)
+		}
+
+		tracker, ok := ctx.Value(loopTrackerKey{}).(*loopTracker)
+		if !ok {
+			tracker = &loopTracker{visits: make(map[string]int)}
+			ctx = context.WithValue(ctx, loopTrackerKey{}, tracker)
+		}
+
+		tracker.mu.Lock()
+		tracker.visits[name]++
+		visits := tracker.visits[name]
+		tracker.mu.Unlock()
+
+		defer func() {
+			tracker.mu.Lock()
+			tracker.visits[name]--
+			tracker.mu.Unlock()
+		}()
+
+		if visits > maxEntries {
+			var zero O
+			return zero, fmt.Errorf("%w: lambda '%s' entered %d times (limit %d)", ErrLoopDetected, name, visits, maxEntries)
+		}
+
+		return next(ctx, input)
+	}
+}
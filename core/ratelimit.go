@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter 限流器接口，RateLimit 与 RateLimitWait 中间件均基于该接口
+// 内置限流器的构造函数在容量、速率或窗口不为正数时 panic：这些参数没有可以安全截断到的取值，
+// 截断只会得到永远拒绝或在 Wait 中空转的限流器。
+type Limiter interface {
+	// Allow 立即判断是否放行，不阻塞
+	Allow() bool
+	// Wait 阻塞直到放行或 ctx 结束
+	Wait(ctx context.Context) error
+}
+
+// Wait 阻塞直到固定窗口内有可用配额或 ctx 结束
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		if rl.Allow() {
+			return nil
+		}
+
+		rl.mu.Lock()
+		delay := rl.window
+		if len(rl.requests) > 0 {
+			delay = rl.window - time.Since(rl.requests[0])
+		}
+		rl.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// TokenBucketLimiter 令牌桶限流器
+// 桶容量为 capacity，令牌以 refillRate 个/秒的速率补充；
+// 与固定窗口相比，突发量受 capacity 限制，稳态吞吐等于 refillRate。
+type TokenBucketLimiter struct {
+	mu         sync.Mutex
+	capacity   float64
+	refillRate float64
+	tokens     float64
+	last       time.Time
+}
+
+// NewTokenBucketLimiter 创建令牌桶限流器，初始时桶是满的
+// capacity 与 refillRate 必须为正数，否则 panic。
+func NewTokenBucketLimiter(capacity int, refillRate float64) *TokenBucketLimiter {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("core: token bucket capacity must be positive, got %d", capacity))
+	}
+	if !(refillRate > 0) {
+		panic(fmt.Sprintf("core: token bucket refill rate must be positive, got %v", refillRate))
+	}
+	return &TokenBucketLimiter{
+		capacity:   float64(capacity),
+		refillRate: refillRate,
+		tokens:     float64(capacity),
+		last:       time.Now(),
+	}
+}
+
+// refill 按经过的时间补充令牌，需在持有锁时调用
+func (tb *TokenBucketLimiter) refill(now time.Time) {
+	elapsed := now.Sub(tb.last).Seconds()
+	if elapsed > 0 {
+		tb.tokens += elapsed * tb.refillRate
+		if tb.tokens > tb.capacity {
+			tb.tokens = tb.capacity
+		}
+	}
+	tb.last = now
+}
+
+// Allow 有可用令牌时消耗一个并返回 true
+func (tb *TokenBucketLimiter) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill(time.Now())
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait 阻塞直到取得一个令牌或 ctx 结束
+func (tb *TokenBucketLimiter) Wait(ctx context.Context) error {
+	for {
+		tb.mu.Lock()
+		tb.refill(time.Now())
+		if tb.tokens >= 1 {
+			tb.tokens--
+			tb.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - tb.tokens) / tb.refillRate * float64(time.Second))
+		tb.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// RateLimitWait 阻塞式限流中间件
+// 与 RateLimit 快速失败不同，调用会等待直到限流器放行；ctx 结束时返回 ctx 的错误。
+func RateLimitWait[I any, O any](limiter Limiter) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			var zero O
+			return zero, fmt.Errorf("rate limit wait: %w", err)
+		}
+
+		return next(ctx, input)
+	}
+}
+
+// sleepContext 等待 d 或 ctx 结束
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SlidingWindowLimiter 滑动窗口限流器
+// 以上一窗口的计数按剩余比例加权估算当前速率，避免固定窗口在边界处放行两倍请求的问题。
+type SlidingWindowLimiter struct {
+	mu          sync.Mutex
+	maxRequests int
+	window      time.Duration
+	windowStart time.Time
+	previous    int
+	current     int
+}
+
+// NewSlidingWindowLimiter 创建滑动窗口限流器
+// maxRequests 与 window 必须为正数，否则 panic。
+func NewSlidingWindowLimiter(maxRequests int, window time.Duration) *SlidingWindowLimiter {
+	if maxRequests <= 0 {
+		panic(fmt.Sprintf("core: sliding window maxRequests must be positive, got %d", maxRequests))
+	}
+	if window <= 0 {
+		panic(fmt.Sprintf("core: sliding window must be positive, got %v", window))
+	}
+	return &SlidingWindowLimiter{
+		maxRequests: maxRequests,
+		window:      window,
+		windowStart: time.Now(),
+	}
+}
+
+// advance 滚动窗口并返回当前估算的请求数，需在持有锁时调用
+func (sw *SlidingWindowLimiter) advance(now time.Time) float64 {
+	elapsed := now.Sub(sw.windowStart)
+	if elapsed >= sw.window {
+		windows := elapsed / sw.window
+		if windows == 1 {
+			sw.previous = sw.current
+		} else {
+			sw.previous = 0
+		}
+		sw.current = 0
+		sw.windowStart = sw.windowStart.Add(windows * sw.window)
+		elapsed = now.Sub(sw.windowStart)
+	}
+
+	weight := 1 - float64(elapsed)/float64(sw.window)
+	return float64(sw.previous)*weight + float64(sw.current)
+}
+
+// Allow 估算值未达上限时计数并返回 true
+func (sw *SlidingWindowLimiter) Allow() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.advance(time.Now()) >= float64(sw.maxRequests) {
+		return false
+	}
+	sw.current++
+	return true
+}
+
+// Wait 阻塞直到放行或 ctx 结束
+func (sw *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	delay := sw.window / time.Duration(sw.maxRequests)
+
+	for !sw.Allow() {
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// weightedRequest 带权重的请求记录
+type weightedRequest struct {
+	at     time.Time
+	weight int
+}
+
+// WeightedRateLimit 按资源开销加权的限流中间件
+// weight 估算每个输入的开销，window 内已放行请求的权重之和加上本次权重超过 capacity 时拒绝，
+// 因此少量重请求即可耗尽容量，而大量轻请求仍可放行。权重小于 1 时按 1 计。
+func WeightedRateLimit[I any, O any](capacity int, weight func(I) int, window time.Duration) Middleware[I, O] {
+	var mu sync.Mutex
+	var requests []weightedRequest
+	used := 0
+
+	admit := func(w int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		expired := 0
+		for _, req := range requests {
+			if now.Sub(req.at) < window {
+				break
+			}
+			used -= req.weight
+			expired++
+		}
+		requests = requests[expired:]
+
+		if used+w > capacity {
+			return false
+		}
+		used += w
+		requests = append(requests, weightedRequest{at: now, weight: w})
+		return true
+	}
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		w := weight(input)
+		if w < 1 {
+			w = 1
+		}
+
+		if !admit(w) {
+			var zero O
+			return zero, fmt.Errorf("%w: weight %d over capacity %d", ErrRateLimited, w, capacity)
+		}
+
+		return next(ctx, input)
+	}
+}
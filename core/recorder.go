@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RecordedCall 一次被记录的调用
+type RecordedCall[I any, O any] struct {
+	Input     I             `json:"input"`
+	Output    O             `json:"output"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// Recorder 调用记录中间件，每次调用完成后把输入、输出、错误与耗时交给 sink
+// 用于在线下重放线上问题（见 Replay）；sink 同步调用，需自行保证并发安全。
+func Recorder[I any, O any](sink func(RecordedCall[I, O])) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		start := time.Now()
+		output, err := next(ctx, input)
+
+		call := RecordedCall[I, O]{
+			Input:     input,
+			Output:    output,
+			Duration:  Since(start),
+			Timestamp: start,
+		}
+		if err != nil {
+			call.Error = err.Error()
+		}
+		sink(call)
+
+		return output, err
+	}
+}
+
+// Invokable 可按输入调用并返回结果的lambda，*Lambda 与 *LambdaWithMiddleware 都满足该接口
+type Invokable[I any, O any] interface {
+	Invoke(ctx context.Context, input I) (*LambdaResult[O], error)
+}
+
+// ReplayResult 一次重放的结果
+type ReplayResult[I any, O any] struct {
+	Call   RecordedCall[I, O]
+	Output O
+	Err    error
+	// Match 重放的输出与错误信息是否与记录一致（输出以 reflect.DeepEqual 比较）
+	Match bool
+}
+
+// Replay 以记录的输入依次重新调用lambda，返回与 recorded 一一对应的结果
+func Replay[I any, O any](ctx context.Context, recorded []RecordedCall[I, O], lambda Invokable[I, O]) []ReplayResult[I, O] {
+	results := make([]ReplayResult[I, O], len(recorded))
+
+	for i, call := range recorded {
+		replayed := ReplayResult[I, O]{Call: call}
+
+		result, err := lambda.Invoke(ctx, call.Input)
+		if result != nil {
+			replayed.Output = result.Output
+		}
+		replayed.Err = err
+
+		errMessage := ""
+		if err != nil {
+			errMessage = err.Error()
+		}
+		replayed.Match = errMessage == call.Error && reflect.DeepEqual(replayed.Output, call.Output)
+
+		results[i] = replayed
+	}
+
+	return results
+}
+
+// JSONFileRecorder 以 JSON Lines 格式把调用记录追加到文件的 sink，输入与输出需可 JSON 序列化
+type JSONFileRecorder[I any, O any] struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+// NewJSONFileRecorder 打开（不存在时创建）记录文件，新记录追加到文件末尾
+func NewJSONFileRecorder[I any, O any](path string) (*JSONFileRecorder[I, O], error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	return &JSONFileRecorder[I, O]{file: file}, nil
+}
+
+// Record 追加一条记录，可直接作为 Recorder 的 sink
+// 写入失败不会影响调用，第一个错误由 Err 返回
+func (r *JSONFileRecorder[I, O]) Record(call RecordedCall[I, O]) {
+	data, err := json.Marshal(call)
+	if err == nil {
+		data = append(data, '\n')
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		_, err = r.file.Write(data)
+	}
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err 返回第一个写入错误
+func (r *JSONFileRecorder[I, O]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close 关闭记录文件
+func (r *JSONFileRecorder[I, O]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// LoadRecordedCalls 读取 JSONFileRecorder 写出的记录
+func LoadRecordedCalls[I any, O any](path string) ([]RecordedCall[I, O], error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	defer file.Close()
+
+	var calls []RecordedCall[I, O]
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var call RecordedCall[I, O]
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		calls = append(calls, call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read record file: %w", err)
+	}
+
+	return calls, nil
+}
@@ -0,0 +1,90 @@
+package core
+
+// Result 输出与错误的组合，便于链式处理调用结果而无需反复检查错误
+// 出错的 Result 上的 Map、FlatMap 不会调用传入的函数，错误原样向后传递
+type Result[O any] struct {
+	value O
+	err   error
+}
+
+// Ok 创建成功的 Result
+func Ok[O any](value O) Result[O] {
+	return Result[O]{value: value}
+}
+
+// Fail 创建失败的 Result
+func Fail[O any](err error) Result[O] {
+	return Result[O]{err: err}
+}
+
+// ResultOf 把lambda调用的返回值转换为 Result，可直接传入 Invoke 的返回值：
+//
+//	core.ResultOf(lambda.Invoke(ctx, input)).Map(normalize).OrElse(fallback)
+func ResultOf[O any](result *LambdaResult[O], err error) Result[O] {
+	if err != nil {
+		return Fail[O](err)
+	}
+	if result == nil {
+		var zero O
+		return Ok(zero)
+	}
+	if result.Error != nil {
+		return Fail[O](result.Error)
+	}
+	return Ok(result.Output)
+}
+
+// IsOk 判断是否成功
+func (r Result[O]) IsOk() bool {
+	return r.err == nil
+}
+
+// Value 返回输出与错误
+func (r Result[O]) Value() (O, error) {
+	return r.value, r.err
+}
+
+// Err 返回错误，成功时为 nil
+func (r Result[O]) Err() error {
+	return r.err
+}
+
+// Map 成功时以 fn 转换输出
+func (r Result[O]) Map(fn func(O) O) Result[O] {
+	if r.err != nil {
+		return r
+	}
+	return Ok(fn(r.value))
+}
+
+// FlatMap 成功时以可能失败的 fn 继续处理
+func (r Result[O]) FlatMap(fn func(O) Result[O]) Result[O] {
+	if r.err != nil {
+		return r
+	}
+	return fn(r.value)
+}
+
+// OrElse 成功时返回输出，失败时返回 fallback
+func (r Result[O]) OrElse(fallback O) O {
+	if r.err != nil {
+		return fallback
+	}
+	return r.value
+}
+
+// MapResult 成功时以 fn 把输出转换为其它类型（方法不能引入新的类型参数，因此以函数提供）
+func MapResult[O any, R any](r Result[O], fn func(O) R) Result[R] {
+	if r.err != nil {
+		return Fail[R](r.err)
+	}
+	return Ok(fn(r.value))
+}
+
+// FlatMapResult 成功时以可能失败的 fn 把输出转换为其它类型
+func FlatMapResult[O any, R any](r Result[O], fn func(O) Result[R]) Result[R] {
+	if r.err != nil {
+		return Fail[R](r.err)
+	}
+	return fn(r.value)
+}
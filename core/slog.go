@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogOptions 结构化日志中间件配置
+type SlogOptions[I any, O any] struct {
+	// 输入摘要函数，为空时不记录输入
+	SummarizeInput func(I) any
+	// 输出摘要函数，为空时不记录输出
+	SummarizeOutput func(O) any
+	// 成功时的日志级别
+	SuccessLevel slog.Level
+	// 失败时的日志级别
+	ErrorLevel slog.Level
+}
+
+// SlogOption 结构化日志中间件选项函数
+type SlogOption[I any, O any] func(*SlogOptions[I, O])
+
+// WithInputSummary 设置输入摘要函数
+func WithInputSummary[I any, O any](summarize func(I) any) SlogOption[I, O] {
+	return func(opts *SlogOptions[I, O]) {
+		opts.SummarizeInput = summarize
+	}
+}
+
+// WithOutputSummary 设置输出摘要函数
+func WithOutputSummary[I any, O any](summarize func(O) any) SlogOption[I, O] {
+	return func(opts *SlogOptions[I, O]) {
+		opts.SummarizeOutput = summarize
+	}
+}
+
+// WithSlogLevels 设置成功和失败时的日志级别
+func WithSlogLevels[I any, O any](success, failure slog.Level) SlogOption[I, O] {
+	return func(opts *SlogOptions[I, O]) {
+		opts.SuccessLevel = success
+		opts.ErrorLevel = failure
+	}
+}
+
+// SlogLogger 结构化日志中间件
+// 每次调用输出一条日志，包含 name、duration_ms、error、request_id（context 中有请求ID时）以及可选的输入/输出摘要
+func SlogLogger[I any, O any](logger *slog.Logger, name string, opts ...SlogOption[I, O]) Middleware[I, O] {
+	options := &SlogOptions[I, O]{
+		SuccessLevel: slog.LevelInfo,
+		ErrorLevel:   slog.LevelError,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		start := time.Now()
+		output, err := next(ctx, input)
+		duration := Since(start)
+
+		attrs := []slog.Attr{
+			slog.String("name", name),
+			slog.Float64("duration_ms", float64(duration)/float64(time.Millisecond)),
+		}
+		if id, ok := RequestIDFromContext(ctx); ok {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if options.SummarizeInput != nil {
+			attrs = append(attrs, slog.Any("input", options.SummarizeInput(input)))
+		}
+
+		level := options.SuccessLevel
+		if err != nil {
+			level = options.ErrorLevel
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else if options.SummarizeOutput != nil {
+			attrs = append(attrs, slog.Any("output", options.SummarizeOutput(output)))
+		}
+
+		logger.LogAttrs(ctx, level, "lambda invocation", attrs...)
+		return output, err
+	}
+}
+
+// RequestIDMetadataKey RequestIDLogger 在结果元数据中记录请求ID使用的键
+const RequestIDMetadataKey = "request_id"
+
+// RequestIDLogger 请求ID日志中间件
+// 沿用 context 中的请求ID或生成新的ID（见 EnsureRequestID），以携带该ID的 context 调用 next，
+// 使调用链后续的中间件与处理器都能读到同一个ID；处理器执行前后各输出一条带 request_id 的日志，
+// 并把ID记录到结果元数据的 RequestIDMetadataKey 下。
+func RequestIDLogger[I any, O any](logger *slog.Logger, name string) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		ctx, id := EnsureRequestID(ctx)
+		SetResultMetadata(ctx, RequestIDMetadataKey, id)
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "lambda invocation started",
+			slog.String("name", name),
+			slog.String("request_id", id),
+		)
+
+		start := time.Now()
+		output, err := next(ctx, input)
+
+		attrs := []slog.Attr{
+			slog.String("name", name),
+			slog.String("request_id", id),
+			slog.Float64("duration_ms", float64(Since(start))/float64(time.Millisecond)),
+		}
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, level, "lambda invocation finished", attrs...)
+
+		return output, err
+	}
+}
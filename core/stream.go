@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+)
+
+// StreamFunc 双向流lambda函数类型
+// 从 input 读取输入，将输出写入 output，input 关闭后返回
+type StreamFunc[I any, O any] func(ctx context.Context, input <-chan I, output chan<- O) error
+
+// StreamLambda 双向流lambda
+type StreamLambda[I any, O any] struct {
+	name   string
+	stream StreamFunc[I, O]
+}
+
+// NewStreamLambda 创建新的双向流lambda
+func NewStreamLambda[I any, O any](name string, stream StreamFunc[I, O]) *StreamLambda[I, O] {
+	return &StreamLambda[I, O]{
+		name:   name,
+		stream: stream,
+	}
+}
+
+// Invoke 执行双向流lambda，返回后关闭 output
+func (l *StreamLambda[I, O]) Invoke(ctx context.Context, input <-chan I, output chan<- O) error {
+	defer close(output)
+	return l.stream(ctx, input, output)
+}
+
+// GetName 获取lambda名称
+func (l *StreamLambda[I, O]) GetName() string {
+	return l.name
+}
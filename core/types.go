@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 )
@@ -40,6 +41,18 @@ type LambdaOptions struct {
 	EnableCallback bool
 	// 组件实现类型
 	ComponentType string
+	// 标签
+	Tags []string
+	// 是否将处理器panic转换为错误
+	Recover bool
+	// 是否在独立的goroutine中执行处理器
+	IsolatedExecution bool
+	// 生命周期钩子，按添加顺序依次调用
+	OnStart   []func(ctx context.Context)
+	OnSuccess []func(ctx context.Context, duration time.Duration)
+	OnError   []func(ctx context.Context, err error, duration time.Duration)
+	// 附加的结构化配置，供处理器与中间件按键读取
+	Config map[any]any
 }
 
 // LambdaMetrics lambda指标统计
@@ -48,9 +61,21 @@ type LambdaMetrics struct {
 	TotalInvocations   int64
 	SuccessInvocations int64
 	ErrorInvocations   int64
+	TimeoutInvocations int64 // 超时的调用次数，同时计入 ErrorInvocations
+	RetryInvocations   int64 // 发生过重试的调用次数
+	TotalRetries       int64 // 重试的总次数（不含首次调用）
 	TotalDuration      time.Duration
 	AverageDuration    time.Duration
+	SumSquares         float64       // 各次耗时（纳秒）的平方和，用于计算标准差
+	StdDevDuration     time.Duration // 耗时的总体标准差
 	LastInvocationTime time.Time
+
+	subscribers    map[int]chan MetricsSnapshot
+	nextSubscriber int
+
+	// 最近 latencyWindow 次调用的耗时（环形缓冲），用于计算分位数
+	recent     []time.Duration
+	recentNext int
 }
 
 // LambdaResult lambda调用结果
@@ -59,14 +84,24 @@ type LambdaResult[O any] struct {
 	Error     error
 	Duration  time.Duration
 	Timestamp time.Time
+	Metadata  map[string]any // 中间件通过 SetResultMetadata 记录的附加信息
+	Attempts  int            // 处理器的实际调用次数（含重试），未调用时为 0
+}
+
+// Pair 二元输入，用于偏应用等场景
+type Pair[A any, B any] struct {
+	First  A
+	Second B
 }
 
 // LambdaMeta lambda元数据
 type LambdaMeta struct {
 	Name          string
+	Namespace     string
 	InputType     string
 	OutputType    string
 	ComponentType string
+	Tags          []string
 	RegisteredAt  time.Time
 }
 
@@ -125,4 +160,91 @@ func WithComponentType(componentType string) LambdaOption {
 	return func(opts *LambdaOptions) {
 		opts.ComponentType = componentType
 	}
-}
\ No newline at end of file
+}
+
+// WithTags 追加标签
+func WithTags(tags ...string) LambdaOption {
+	return func(opts *LambdaOptions) {
+		newTags := make([]string, 0, len(opts.Tags)+len(tags))
+		newTags = append(newTags, opts.Tags...)
+		opts.Tags = append(newTags, tags...)
+	}
+}
+
+// WithRecover 将处理器panic转换为错误返回
+func WithRecover() LambdaOption {
+	return func(opts *LambdaOptions) {
+		opts.Recover = true
+	}
+}
+
+// WithOnStart 添加调用开始时执行的钩子
+func WithOnStart(hook func(ctx context.Context)) LambdaOption {
+	return func(opts *LambdaOptions) {
+		// 截断容量后追加，避免与共享同一底层数组的选项副本互相覆盖
+		opts.OnStart = append(slices.Clip(opts.OnStart), hook)
+	}
+}
+
+// WithOnSuccess 添加调用成功后执行的钩子
+func WithOnSuccess(hook func(ctx context.Context, duration time.Duration)) LambdaOption {
+	return func(opts *LambdaOptions) {
+		opts.OnSuccess = append(slices.Clip(opts.OnSuccess), hook)
+	}
+}
+
+// WithOnError 添加调用失败后执行的钩子
+func WithOnError(hook func(ctx context.Context, err error, duration time.Duration)) LambdaOption {
+	return func(opts *LambdaOptions) {
+		opts.OnError = append(slices.Clip(opts.OnError), hook)
+	}
+}
+
+// WithConfig 附加一项结构化配置，同一键后设置的值覆盖先前的值
+// 调用时处理器与中间件可通过 ConfigFromContext 读取，也可通过 Lambda.Config 直接读取
+func WithConfig(key, value any) LambdaOption {
+	return func(opts *LambdaOptions) {
+		// 复制后再写入，避免影响共享同一映射的选项副本
+		config := make(map[any]any, len(opts.Config)+1)
+		for k, v := range opts.Config {
+			config[k] = v
+		}
+		config[key] = value
+		opts.Config = config
+	}
+}
+
+// OptionPreset 把多个选项组合为一个可复用的选项，按顺序应用
+func OptionPreset(opts ...LambdaOption) LambdaOption {
+	return func(options *LambdaOptions) {
+		for _, opt := range opts {
+			opt(options)
+		}
+	}
+}
+
+// ResilientPreset 面向可靠性的预设：30秒超时、3次重试、启用指标并恢复panic
+func ResilientPreset() LambdaOption {
+	return OptionPreset(
+		WithTimeout(30*time.Second),
+		WithRetries(3),
+		WithEnableMetrics(true),
+		WithRecover(),
+	)
+}
+
+// FastPreset 面向低延迟的预设：1秒超时、不重试、关闭指标收集
+func FastPreset() LambdaOption {
+	return OptionPreset(
+		WithTimeout(time.Second),
+		WithRetries(0),
+		WithEnableMetrics(false),
+	)
+}
+
+// WithIsolatedExecution 在独立的goroutine中执行处理器并恢复其panic
+func WithIsolatedExecution() LambdaOption {
+	return func(opts *LambdaOptions) {
+		opts.IsolatedExecution = true
+	}
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationErrors 字段级校验错误，键为字段名，值为错误信息
+type ValidationErrors struct {
+	Fields map[string]string
+}
+
+// Error 实现 error 接口，字段按名称排序输出
+func (e *ValidationErrors) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %s", name, e.Fields[name])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// FieldRule 字段校验函数，校验通过时返回空字符串
+type FieldRule func(value any) string
+
+// FieldValidator 字段级输入校验中间件
+// rules 以字段名为键，通过反射读取输入结构体（或其指针）的字段值并校验，
+// 任一字段不通过时返回 *ValidationErrors 且不调用 next
+func FieldValidator[I any, O any](rules map[string]FieldRule) Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if errs := validateFields(input, rules); errs != nil {
+			var zero O
+			return zero, errs
+		}
+
+		return next(ctx, input)
+	}
+}
+
+// validateFields 按规则校验结构体字段，全部通过时返回 nil
+func validateFields(input any, rules map[string]FieldRule) *ValidationErrors {
+	value := reflect.ValueOf(input)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return &ValidationErrors{Fields: map[string]string{"": "input is nil"}}
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return &ValidationErrors{Fields: map[string]string{"": fmt.Sprintf("expected struct input, got %s", value.Kind())}}
+	}
+
+	fields := make(map[string]string)
+	for name, rule := range rules {
+		field := value.FieldByName(name)
+		if !field.IsValid() {
+			fields[name] = "unknown field"
+			continue
+		}
+		if !field.CanInterface() {
+			fields[name] = "unexported field"
+			continue
+		}
+		if msg := rule(field.Interface()); msg != "" {
+			fields[name] = msg
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationErrors{Fields: fields}
+}
+
+// ValidateStruct 基于结构体标签的输入校验中间件
+// 读取输入结构体（或其指针）导出字段上的 validate 标签，规则以逗号分隔：
+//
+//	required  值不能为零值
+//	min=N     字符串/切片/映射的长度或数值不小于 N
+//	max=N     字符串/切片/映射的长度或数值不大于 N
+//	email     字符串为合法的邮箱地址（空字符串跳过，需配合 required）
+//
+// 所有违规字段汇总为 *ValidationErrors 返回，且不调用 next
+func ValidateStruct[I any, O any]() Middleware[I, O] {
+	return func(ctx context.Context, input I, next InvokeFunc[I, O]) (O, error) {
+		if errs := validateStructTags(input); errs != nil {
+			var zero O
+			return zero, errs
+		}
+
+		return next(ctx, input)
+	}
+}
+
+// validateStructTags 按 validate 标签校验结构体，全部通过时返回 nil
+func validateStructTags(input any) *ValidationErrors {
+	value := reflect.ValueOf(input)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return &ValidationErrors{Fields: map[string]string{"": "input is nil"}}
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return &ValidationErrors{Fields: map[string]string{"": fmt.Sprintf("expected struct input, got %s", value.Kind())}}
+	}
+
+	fields := make(map[string]string)
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		var violations []string
+		for _, rule := range strings.Split(tag, ",") {
+			if msg := checkTagRule(value.Field(i), strings.TrimSpace(rule)); msg != "" {
+				violations = append(violations, msg)
+			}
+		}
+		if len(violations) > 0 {
+			fields[field.Name] = strings.Join(violations, ", ")
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationErrors{Fields: fields}
+}
+
+// checkTagRule 校验单条规则，通过时返回空字符串
+func checkTagRule(field reflect.Value, rule string) string {
+	name, param, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "":
+		return ""
+	case "required":
+		if field.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+		size, isLength, ok := measure(field)
+		if !ok {
+			return fmt.Sprintf("rule %q not supported for %s", rule, field.Kind())
+		}
+		if name == "min" && size < limit {
+			if isLength {
+				return fmt.Sprintf("length must be at least %s", param)
+			}
+			return fmt.Sprintf("must be at least %s", param)
+		}
+		if name == "max" && size > limit {
+			if isLength {
+				return fmt.Sprintf("length must be at most %s", param)
+			}
+			return fmt.Sprintf("must be at most %s", param)
+		}
+	case "email":
+		if field.Kind() != reflect.String {
+			return fmt.Sprintf("rule %q not supported for %s", rule, field.Kind())
+		}
+		if s := field.String(); s != "" && !isEmail(s) {
+			return "must be a valid email address"
+		}
+	default:
+		return fmt.Sprintf("unknown rule %q", rule)
+	}
+	return ""
+}
+
+// measure 返回用于 min/max 比较的值，以及该值是否为长度
+func measure(field reflect.Value) (float64, bool, bool) {
+	switch field.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(field.String())), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(field.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(field.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// isEmail 判断字符串是否为不带显示名的邮箱地址
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ZHLX2005/minilambda/invoker"
+)
+
+// Handler 事件处理函数
+type Handler func(ctx context.Context, payload any) error
+
+// Bus 进程内的发布/订阅总线
+// 事件同步投递给所有订阅者，Publish 返回所有订阅者错误的合并
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[int]Handler
+	nextID      int
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string]map[int]Handler),
+	}
+}
+
+// Subscribe 订阅主题，返回取消订阅函数
+func (b *Bus) Subscribe(topic string, handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[int]Handler)
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[topic][id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[topic], id)
+	}
+}
+
+// Publish 向主题发布事件
+func (b *Bus) Publish(topic string, payload any) error {
+	return b.PublishContext(context.Background(), topic, payload)
+}
+
+// PublishContext 携带 context 向主题发布事件
+func (b *Bus) PublishContext(ctx context.Context, topic string, payload any) error {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.subscribers[topic]))
+	for _, handler := range b.subscribers[topic] {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BindLambda 将lambda绑定到主题：主题上每个类型为 I 的事件都会触发一次调用
+// resultTopic 非空时，调用成功的输出会发布到该主题；类型不匹配时返回错误而不会panic
+func BindLambda[I any, O any](bus *Bus, topic, name, resultTopic string) func() {
+	inv := invoker.NewInvoker[I, O]()
+
+	return bus.Subscribe(topic, func(ctx context.Context, payload any) error {
+		input, ok := payload.(I)
+		if !ok {
+			var zero I
+			return fmt.Errorf("topic '%s': lambda '%s' expects %T, got %T", topic, name, zero, payload)
+		}
+
+		result, err := inv.Invoke(ctx, name, input)
+		if err != nil {
+			return fmt.Errorf("topic '%s': lambda '%s' failed: %w", topic, name, err)
+		}
+
+		if resultTopic != "" {
+			return bus.PublishContext(ctx, resultTopic, result.Output)
+		}
+		return nil
+	})
+}
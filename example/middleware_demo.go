@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ZHLX2005/minilambda/contextx"
 	"github.com/ZHLX2005/minilambda/core"
 )
 
@@ -99,12 +100,17 @@ func Auth[I any, O any](requiredRole string) core.Middleware[I, O] {
 		fmt.Printf("  [Auth] Checking required role: %s\n", requiredRole)
 
 		// 从 context 获取用户信息
-		if userID := ctx.Value("user_id"); userID == nil {
+		user, ok := contextx.UserFromContext(ctx)
+		if !ok {
 			var zero O
 			return zero, errors.New("unauthorized: no user in context")
 		}
+		if !user.HasRole(requiredRole) {
+			var zero O
+			return zero, fmt.Errorf("forbidden: user %d lacks role %s", user.ID, requiredRole)
+		}
 
-		fmt.Printf("  [Auth] User authenticated\n")
+		fmt.Printf("  [Auth] User %d authenticated\n", user.ID)
 		return next(ctx, input)
 	}
 }
@@ -424,7 +430,7 @@ func demoCustomMiddleware() {
 
 	// 带认证
 	fmt.Println("  Request with authentication:")
-	authCtx := context.WithValue(context.Background(), "user_id", 42)
+	authCtx := contextx.WithUser(context.Background(), contextx.User{ID: 42, Roles: []string{"user"}})
 	result, err := lambda.Invoke(authCtx, 123)
 	if err != nil {
 		fmt.Printf("    Error: %v\n", err)
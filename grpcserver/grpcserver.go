@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+// Code 状态码，取值与 gRPC 的 codes.Code 一致
+type Code uint32
+
+const (
+	OK                Code = 0
+	Canceled          Code = 1
+	Unknown           Code = 2
+	InvalidArgument   Code = 3
+	DeadlineExceeded  Code = 4
+	NotFound          Code = 5
+	ResourceExhausted Code = 8
+	Internal          Code = 13
+)
+
+// String 返回状态码名称
+func (c Code) String() string {
+	switch c {
+	case OK:
+		return "OK"
+	case Canceled:
+		return "Canceled"
+	case InvalidArgument:
+		return "InvalidArgument"
+	case DeadlineExceeded:
+		return "DeadlineExceeded"
+	case NotFound:
+		return "NotFound"
+	case ResourceExhausted:
+		return "ResourceExhausted"
+	case Internal:
+		return "Internal"
+	default:
+		return "Unknown"
+	}
+}
+
+// Status 带状态码的错误
+type Status struct {
+	Code    Code
+	Message string
+	cause   error
+}
+
+// Error 实现 error 接口
+func (s *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %s desc = %s", s.Code, s.Message)
+}
+
+// Unwrap 返回原始错误
+func (s *Status) Unwrap() error {
+	return s.cause
+}
+
+// InvokeRequest 调用请求
+type InvokeRequest struct {
+	Name    string
+	Payload []byte
+	Gzip    bool // 负载为 gzip 压缩的 JSON，响应负载同样压缩
+}
+
+// InvokeResponse 调用响应
+type InvokeResponse struct {
+	Payload []byte
+	Gzip    bool
+}
+
+// DispatchServer 按名称把请求分发给已注册lambda的服务
+// 与传输层无关：本包不依赖 google.golang.org/grpc，不提供 ServiceDesc、proto 消息与编解码器，
+// 不能直接注册到 *grpc.Server。接入 gRPC 时需由调用方生成服务描述并在处理函数中转调 Invoke，
+// 状态码取值与 gRPC 一致，可直接转换为 codes.Code。
+type DispatchServer struct{}
+
+// NewDispatchServer 创建分发服务
+func NewDispatchServer() *DispatchServer {
+	return &DispatchServer{}
+}
+
+// Invoke 以 JSON 编码的负载调用lambda
+// 传入 context 的截止时间会传递给lambda，错误映射为对应的状态码
+func (s *DispatchServer) Invoke(ctx context.Context, req *InvokeRequest) (*InvokeResponse, error) {
+	if req == nil || req.Name == "" {
+		return nil, &Status{Code: InvalidArgument, Message: "lambda name is required"}
+	}
+
+	payload := req.Payload
+	if req.Gzip {
+		raw, err := core.GzipDecompress(payload)
+		if err != nil {
+			return nil, &Status{Code: InvalidArgument, Message: fmt.Sprintf("invalid gzip payload: %v", err), cause: err}
+		}
+		payload = raw
+	}
+
+	output, err := registry.InvokeJSON(ctx, req.Name, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if req.Gzip {
+		compressed, err := core.GzipCompress(output)
+		if err != nil {
+			return nil, &Status{Code: Internal, Message: err.Error(), cause: err}
+		}
+		return &InvokeResponse{Payload: compressed, Gzip: true}, nil
+	}
+
+	return &InvokeResponse{Payload: output}, nil
+}
+
+// CodeOf 返回错误对应的状态码
+func CodeOf(err error) Code {
+	if err == nil {
+		return OK
+	}
+
+	var status *Status
+	if errors.As(err, &status) {
+		return status.Code
+	}
+	return Unknown
+}
+
+// toStatus 把调用错误映射为状态错误
+func toStatus(err error) *Status {
+	code := Unknown
+	switch {
+	case errors.Is(err, registry.ErrLambdaNotFound):
+		code = NotFound
+	case errors.Is(err, registry.ErrInvalidPayload):
+		code = InvalidArgument
+	case errors.Is(err, core.ErrRateLimited):
+		code = ResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		code = DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = Canceled
+	}
+
+	return &Status{Code: code, Message: err.Error(), cause: err}
+}
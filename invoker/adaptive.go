@@ -0,0 +1,117 @@
+package invoker
+
+import (
+	"context"
+	"sync"
+)
+
+// adaptiveLimiter AIMD 自适应并发限制器
+// 成功时并发上限加一，失败时减半，始终保持在 [min, max] 区间内
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	min      int
+	max      int
+	limit    int
+	inFlight int
+	changed  chan struct{}
+}
+
+// newAdaptiveLimiter 创建自适应并发限制器，初始上限为 max
+func newAdaptiveLimiter(min, max int) *adaptiveLimiter {
+	return &adaptiveLimiter{
+		min:     min,
+		max:     max,
+		limit:   max,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire 获取执行许可，超出当前上限时等待
+func (l *adaptiveLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release 归还许可并根据调用结果调整上限
+func (l *adaptiveLimiter) release(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if err != nil {
+		l.limit /= 2
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+	} else if l.limit < l.max {
+		l.limit++
+	}
+
+	// 唤醒等待者
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// current 返回当前并发上限
+func (l *adaptiveLimiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// WithAdaptiveConcurrency 为每个lambda启用 AIMD 自适应并发限制
+// 调用失败时并发上限减半（不低于 min），成功时加一（不超过 max）
+func (inv *Invoker[I, O]) WithAdaptiveConcurrency(min, max int) *Invoker[I, O] {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+
+	inv.adaptiveMin = min
+	inv.adaptiveMax = max
+	inv.limiters = &sync.Map{}
+
+	return inv
+}
+
+// AdaptiveLimit 返回指定lambda当前的自适应并发上限，未启用时返回 0
+func (inv *Invoker[I, O]) AdaptiveLimit(name string) int {
+	limiter := inv.limiterFor(name)
+	if limiter == nil {
+		return 0
+	}
+	return limiter.current()
+}
+
+// limiterFor 获取或创建指定lambda的限制器，未启用时返回 nil
+func (inv *Invoker[I, O]) limiterFor(name string) *adaptiveLimiter {
+	inv.mu.RLock()
+	limiters, min, max := inv.limiters, inv.adaptiveMin, inv.adaptiveMax
+	inv.mu.RUnlock()
+
+	if limiters == nil {
+		return nil
+	}
+
+	limiter, _ := limiters.LoadOrStore(name, newAdaptiveLimiter(min, max))
+	return limiter.(*adaptiveLimiter)
+}
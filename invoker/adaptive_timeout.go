@@ -0,0 +1,56 @@
+package invoker
+
+import (
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// adaptiveTimeoutMinSamples 启用自适应超时所需的最少样本数，样本不足时沿用lambda自身的超时
+const adaptiveTimeoutMinSamples = 20
+
+// WithAdaptiveTimeout 按lambda观测到的 p99 耗时设置每次调用的超时
+// 超时为 p99 × marginFactor（小于 1 时按 1 计算），且不超过lambda自身配置的超时；
+// lambda最近的样本少于 20 个时不调整。marginFactor <= 0 关闭自适应超时。
+func (inv *Invoker[I, O]) WithAdaptiveTimeout(marginFactor float64) *Invoker[I, O] {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if marginFactor > 0 && marginFactor < 1 {
+		marginFactor = 1
+	}
+	inv.adaptiveTimeout = max(marginFactor, 0)
+	return inv
+}
+
+// AdaptiveTimeout 返回下一次调用指定lambda将使用的自适应超时
+// 未启用自适应超时、lambda不存在或样本不足时返回 false
+func (inv *Invoker[I, O]) AdaptiveTimeout(name string) (time.Duration, bool) {
+	lambda, exists := inv.Get(name)
+	if !exists {
+		return 0, false
+	}
+	return inv.adaptiveTimeoutFor(lambda)
+}
+
+// adaptiveTimeoutFor 计算lambda的自适应超时
+func (inv *Invoker[I, O]) adaptiveTimeoutFor(lambda *core.Lambda[I, O]) (time.Duration, bool) {
+	inv.mu.RLock()
+	factor := inv.adaptiveTimeout
+	inv.mu.RUnlock()
+
+	if factor <= 0 {
+		return 0, false
+	}
+
+	p99, samples := lambda.Percentile(99)
+	if samples < adaptiveTimeoutMinSamples || p99 <= 0 {
+		return 0, false
+	}
+
+	timeout := time.Duration(float64(p99) * factor)
+	if configured := lambda.GetOptions().Timeout; configured > 0 && configured < timeout {
+		timeout = configured
+	}
+	return timeout, true
+}
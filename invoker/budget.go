@@ -0,0 +1,132 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// ErrBudgetExhausted 链式调用的总时间预算已用完
+var ErrBudgetExhausted = errors.New("deadline budget exhausted")
+
+// Budget 多个调用共享的总时间预算
+type Budget struct {
+	total    time.Duration
+	deadline time.Time
+}
+
+// budgetContextKey 预算在 context 中的键
+type budgetContextKey struct{}
+
+// WithBudget 返回带有总时间预算的 context
+// 之后的每一步都从剩余预算中扣减，而不是各自重新计时
+func WithBudget(ctx context.Context, total time.Duration) (context.Context, context.CancelFunc) {
+	budget := &Budget{total: total, deadline: time.Now().Add(total)}
+	ctx, cancel := context.WithDeadline(ctx, budget.deadline)
+	return context.WithValue(ctx, budgetContextKey{}, budget), cancel
+}
+
+// BudgetFromContext 返回 context 中的时间预算
+func BudgetFromContext(ctx context.Context) (*Budget, bool) {
+	budget, ok := ctx.Value(budgetContextKey{}).(*Budget)
+	return budget, ok
+}
+
+// Total 返回总预算
+func (b *Budget) Total() time.Duration {
+	return b.total
+}
+
+// Remaining 返回剩余预算，不会小于 0
+func (b *Budget) Remaining() time.Duration {
+	if remaining := time.Until(b.deadline); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// stepContext 从剩余预算派生单步的 context，预算已用完时返回 ErrBudgetExhausted
+func stepContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	budget, ok := BudgetFromContext(ctx)
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	if budget.Remaining() <= 0 {
+		return nil, nil, fmt.Errorf("%w: %v total", ErrBudgetExhausted, budget.total)
+	}
+
+	stepCtx, cancel := context.WithDeadline(ctx, budget.deadline)
+	return stepCtx, cancel, nil
+}
+
+// budgetError 预算到期导致的失败包装为 ErrBudgetExhausted
+func budgetError(ctx context.Context, err error) error {
+	budget, ok := BudgetFromContext(ctx)
+	if ok && errors.Is(err, context.DeadlineExceeded) && budget.Remaining() <= 0 {
+		return fmt.Errorf("%w: %v total: %w", ErrBudgetExhausted, budget.total, err)
+	}
+	return err
+}
+
+// PipelineWithBudget 与 Pipeline 相同，但各步骤共享 ctx 中的时间预算（见 WithBudget）
+// 预算用完后不再开始新的步骤，返回已完成步骤的结果和 ErrBudgetExhausted
+func (inv *Invoker[I, O]) PipelineWithBudget(ctx context.Context, name string, inputs []I) ([]*core.LambdaResult[O], error) {
+	results := make([]*core.LambdaResult[O], 0, len(inputs))
+
+	for i, input := range inputs {
+		stepCtx, cancel, err := stepContext(ctx)
+		if err != nil {
+			return results, fmt.Errorf("pipeline stopped at step %d: %w", i, err)
+		}
+
+		result, err := inv.Invoke(stepCtx, name, input)
+		cancel()
+		if err != nil {
+			return results, fmt.Errorf("pipeline failed at step %d: %w", i, budgetError(ctx, err))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ChainWithBudget 与 Chain 相同，但各步骤共享 ctx 中的时间预算（见 WithBudget）
+func ChainWithBudget[I any, O any](ctx context.Context, steps []ChainStep[I, O]) (*core.LambdaResult[O], error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no steps in chain")
+	}
+
+	var currentInput interface{} = steps[0].Input
+	var totalDuration time.Duration
+
+	for i, step := range steps {
+		typedInput, ok := currentInput.(I)
+		if !ok {
+			return nil, fmt.Errorf("type mismatch at step %d: expected %T, got %T", i, typedInput, currentInput)
+		}
+
+		stepCtx, cancel, err := stepContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("chain stopped at step %d (lambda: %s): %w", i, step.Name, err)
+		}
+
+		result, err := NewInvoker[I, O]().Invoke(stepCtx, step.Name, typedInput)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("chain failed at step %d (lambda: %s): %w", i, step.Name, budgetError(ctx, err))
+		}
+
+		totalDuration += result.Duration
+		currentInput = result.Output
+	}
+
+	return &core.LambdaResult[O]{
+		Output:    currentInput.(O),
+		Duration:  totalDuration,
+		Timestamp: time.Now(),
+	}, nil
+}
@@ -0,0 +1,74 @@
+package invoker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown 调用器正在关闭，不再接收新的调用
+var ErrShuttingDown = errors.New("invoker is shutting down")
+
+// Drainer 跟踪进行中的调用，用于优雅关闭
+// 同一个 Drainer 可以被多个调用器共享（见 Invoker.WithDrainer）
+type Drainer struct {
+	mu       sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{}
+}
+
+// NewDrainer 创建 Drainer
+func NewDrainer() *Drainer {
+	return &Drainer{idle: make(chan struct{})}
+}
+
+// enter 登记一次调用，关闭后返回 ErrShuttingDown
+func (d *Drainer) enter() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return ErrShuttingDown
+	}
+	d.active++
+	return nil
+}
+
+// leave 结束一次调用
+func (d *Drainer) leave() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.active--
+	if d.draining && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// Active 返回进行中的调用数
+func (d *Drainer) Active() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// Shutdown 拒绝新的调用并等待进行中的调用结束
+// ctx 先结束时返回 ctx.Err()，进行中的调用不会被取消
+func (d *Drainer) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -2,17 +2,28 @@ package invoker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ZHLX2005/minilambda/core"
 	"github.com/ZHLX2005/minilambda/registry"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 )
 
 // Invoker lambda调用器
 type Invoker[I any, O any] struct {
-	semaphore chan struct{}
-	mu        sync.RWMutex
+	semaphore   *prioritySemaphore
+	mu          sync.RWMutex
+	limiters    *sync.Map // 自适应并发限制器，按lambda名称索引
+	adaptiveMin int
+	adaptiveMax int
+	namespace   string
+	drainer     *Drainer
+	queue       Queue
+	// 自适应超时倍数，0 表示不启用
+	adaptiveTimeout float64
 }
 
 // NewInvoker 创建新的调用器
@@ -22,7 +33,30 @@ func NewInvoker[I any, O any]() *Invoker[I, O] {
 
 // Get 获取lambda (直接从全局注册表)
 func (inv *Invoker[I, O]) Get(name string) (*core.Lambda[I, O], bool) {
-	return registry.GetLambda[I, O](name)
+	return registry.GetLambda[I, O](registry.QualifiedName(inv.namespace, name))
+}
+
+// WithDrainer 设置 Drainer，调用会登记到 Drainer 中，关闭后新的调用返回 ErrShuttingDown
+func (inv *Invoker[I, O]) WithDrainer(drainer *Drainer) *Invoker[I, O] {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.drainer = drainer
+	return inv
+}
+
+// WithNamespace 设置命名空间，之后按名称查找的lambda都限定在该命名空间内
+// 命名空间包含分隔符 "/" 时 panic，与 registry.RegisterNamespacedLambda 的校验一致。
+func (inv *Invoker[I, O]) WithNamespace(namespace string) *Invoker[I, O] {
+	if err := registry.ValidateNamespacePart(namespace); err != nil {
+		panic(err)
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.namespace = namespace
+	return inv
 }
 
 // WithConcurrency 设置并发限制
@@ -31,7 +65,7 @@ func (inv *Invoker[I, O]) WithConcurrency(concurrency int) *Invoker[I, O] {
 	defer inv.mu.Unlock()
 
 	if concurrency > 0 {
-		inv.semaphore = make(chan struct{}, concurrency)
+		inv.semaphore = newPrioritySemaphore(concurrency)
 	} else {
 		inv.semaphore = nil
 	}
@@ -39,33 +73,71 @@ func (inv *Invoker[I, O]) WithConcurrency(concurrency int) *Invoker[I, O] {
 	return inv
 }
 
+// Waiting 返回因并发限制而等待中的调用数
+func (inv *Invoker[I, O]) Waiting() int {
+	if inv.semaphore == nil {
+		return 0
+	}
+	return inv.semaphore.waiting()
+}
+
 // Invoke 调用指定的lambda
 func (inv *Invoker[I, O]) Invoke(ctx context.Context, name string, input I) (*core.LambdaResult[O], error) {
+	return inv.InvokeWithPriority(ctx, name, input, 0)
+}
+
+// InvokeWithPriority 以指定优先级调用lambda
+// 达到 WithConcurrency 的并发限制时，等待中的调用在名额释放后按优先级从高到低放行，
+// 同优先级先到先得；Invoke 使用优先级 0。未设置并发限制时与 Invoke 相同。
+func (inv *Invoker[I, O]) InvokeWithPriority(ctx context.Context, name string, input I, priority int) (*core.LambdaResult[O], error) {
+	// 优雅关闭
+	if inv.drainer != nil {
+		if err := inv.drainer.enter(); err != nil {
+			return nil, err
+		}
+		defer inv.drainer.leave()
+	}
+
 	// 获取lambda
 	lambda, exists := inv.Get(name)
 	if !exists {
-		return nil, fmt.Errorf("lambda '%s' not found", name)
+		return nil, fmt.Errorf("%w: '%s'", core.ErrLambdaNotFound, name)
 	}
 
 	// 并发控制
 	if inv.semaphore != nil {
-		select {
-		case inv.semaphore <- struct{}{}:
-			defer func() { <-inv.semaphore }()
-		case <-ctx.Done():
-			return nil, ctx.Err()
+		if err := inv.semaphore.acquire(ctx, priority); err != nil {
+			return nil, err
 		}
+		defer inv.semaphore.release()
+	}
+
+	// 自适应超时
+	var opts []core.LambdaOption
+	if timeout, ok := inv.adaptiveTimeoutFor(lambda); ok {
+		opts = append(opts, core.WithTimeout(timeout))
+	}
+
+	// 自适应并发控制
+	if limiter := inv.limiterFor(name); limiter != nil {
+		if err := limiter.acquire(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := lambda.InvokeWithOptions(ctx, input, opts...)
+		limiter.release(err)
+		return result, err
 	}
 
 	// 调用lambda
-	return lambda.Invoke(ctx, input)
+	return lambda.InvokeWithOptions(ctx, input, opts...)
 }
 
 // InvokeAsync 异步调用lambda
 func (inv *Invoker[I, O]) InvokeAsync(ctx context.Context, name string, input I) <-chan *core.LambdaResult[O] {
 	resultChan := make(chan *core.LambdaResult[O], 1)
 
-	go func() {
+	core.Go(func() {
 		defer close(resultChan)
 		result, err := inv.Invoke(ctx, name, input)
 		if err != nil {
@@ -79,20 +151,57 @@ func (inv *Invoker[I, O]) InvokeAsync(ctx context.Context, name string, input I)
 			}
 		}
 		resultChan <- result
-	}()
+	})
 
 	return resultChan
 }
 
+// InvokeCallback 异步调用lambda，完成后以结果调用回调函数
+func (inv *Invoker[I, O]) InvokeCallback(ctx context.Context, name string, input I, cb func(*core.LambdaResult[O])) {
+	resultChan := inv.InvokeAsync(ctx, name, input)
+
+	core.Go(func() {
+		result := <-resultChan
+		if cb != nil {
+			cb(result)
+		}
+	})
+}
+
+// InvokeDuplex 调用双向流lambda
+// 从 inputs 读取输入，返回输出流和错误通道，lambda 结束后两个通道都会关闭
+func (inv *Invoker[I, O]) InvokeDuplex(ctx context.Context, name string, inputs <-chan I) (<-chan O, <-chan error) {
+	outputs := make(chan O)
+	errChan := make(chan error, 1)
+
+	lambda, exists := registry.GetStreamLambda[I, O](name)
+	if !exists {
+		close(outputs)
+		errChan <- fmt.Errorf("%w: stream '%s'", core.ErrLambdaNotFound, name)
+		close(errChan)
+		return outputs, errChan
+	}
+
+	go func() {
+		defer close(errChan)
+		if err := lambda.Invoke(ctx, inputs, outputs); err != nil {
+			errChan <- err
+		}
+	}()
+
+	return outputs, errChan
+}
+
 // InvokeMultiple 调用多个lambda
 func (inv *Invoker[I, O]) InvokeMultiple(ctx context.Context, requests map[string]I) map[string]*core.LambdaResult[O] {
 	results := make(map[string]*core.LambdaResult[O])
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	for name, input := range requests {
+	for _, name := range requestNames(requests) {
+		nm, inp := name, requests[name]
 		wg.Add(1)
-		go func(nm string, inp I) {
+		core.Go(func() {
 			defer wg.Done()
 
 			result, err := inv.Invoke(ctx, nm, inp)
@@ -110,13 +219,276 @@ func (inv *Invoker[I, O]) InvokeMultiple(ctx context.Context, requests map[strin
 			} else {
 				results[nm] = result
 			}
-		}(name, input)
+		})
 	}
 
 	wg.Wait()
 	return results
 }
 
+// requestNames 返回请求的lambda名称，确定性模式下按名称排序
+func requestNames[I any](requests map[string]I) []string {
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, name)
+	}
+	if core.DeterministicMode() {
+		sort.Strings(names)
+	}
+	return names
+}
+
+// InvokeEach 以有限并发用多个输入调用同一个lambda
+// 结果顺序与输入一致，单个输入的处理器错误保存在对应结果的 Error 中
+// concurrency <= 0 时不限制并发，lambda 不存在时直接返回错误
+func (inv *Invoker[I, O]) InvokeEach(ctx context.Context, name string, inputs []I, concurrency int) ([]*core.LambdaResult[O], error) {
+	results := make([]*core.LambdaResult[O], len(inputs))
+	err := inv.invokeEach(ctx, name, inputs, concurrency, func(idx int, result *core.LambdaResult[O]) {
+		results[idx] = result
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// invokeEach 以有限并发调用lambda，每个输入完成后以其下标回调 done
+// done 可能被并发调用；调用均通过 core.Go 启动，lambda 不存在时直接返回错误
+func (inv *Invoker[I, O]) invokeEach(ctx context.Context, name string, inputs []I, concurrency int, done func(idx int, result *core.LambdaResult[O])) error {
+	if _, exists := inv.Get(name); !exists {
+		return fmt.Errorf("%w: '%s'", core.ErrLambdaNotFound, name)
+	}
+
+	if concurrency <= 0 || concurrency > len(inputs) {
+		concurrency = len(inputs)
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, input := range inputs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		idx, inp := i, input
+		core.Go(func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			done(idx, inv.invokeResult(ctx, name, inp))
+		})
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// InvokeFirstSuccess 并行调用多个等价的lambda，返回第一个成功的结果并取消其余调用
+// 全部失败时返回合并后的错误
+func (inv *Invoker[I, O]) InvokeFirstSuccess(ctx context.Context, names []string, input I) (*core.LambdaResult[O], error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no lambdas to invoke")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		name   string
+		result *core.LambdaResult[O]
+		err    error
+	}
+
+	attempts := make(chan attempt, len(names))
+	for _, name := range names {
+		go func(nm string) {
+			result, err := inv.Invoke(ctx, nm, input)
+			attempts <- attempt{name: nm, result: result, err: err}
+		}(name)
+	}
+
+	errs := make([]error, 0, len(names))
+	for range names {
+		a := <-attempts
+		if a.err == nil {
+			return a.result, nil
+		}
+		errs = append(errs, fmt.Errorf("lambda '%s': %w", a.name, a.err))
+	}
+
+	return nil, fmt.Errorf("all lambdas failed: %w", errors.Join(errs...))
+}
+
+// InvokeStream 以有限并发对输入流中的每个输入调用lambda，并以流的形式返回结果
+// 结果按完成顺序输出，不保证与输入顺序一致；需要保持顺序时使用 InvokeStreamOrdered
+// 输入流关闭且全部调用完成，或 ctx 结束时关闭结果流
+func (inv *Invoker[I, O]) InvokeStream(ctx context.Context, name string, inputs <-chan I, concurrency int) <-chan *core.LambdaResult[O] {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make(chan *core.LambdaResult[O])
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				var input I
+				var ok bool
+				select {
+				case input, ok = <-inputs:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+
+				select {
+				case results <- inv.invokeResult(ctx, name, input):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// InvokeStreamOrdered 与 InvokeStream 相同，但结果顺序与输入顺序一致
+func (inv *Invoker[I, O]) InvokeStreamOrdered(ctx context.Context, name string, inputs <-chan I, concurrency int) <-chan *core.LambdaResult[O] {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make(chan *core.LambdaResult[O])
+	pending := make(chan chan *core.LambdaResult[O], concurrency)
+	semaphore := make(chan struct{}, concurrency)
+
+	// 按输入顺序登记结果槽位并启动调用
+	go func() {
+		defer close(pending)
+
+		for {
+			var input I
+			var ok bool
+			select {
+			case input, ok = <-inputs:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			slot := make(chan *core.LambdaResult[O], 1)
+			select {
+			case pending <- slot:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(inp I) {
+				defer func() { <-semaphore }()
+				slot <- inv.invokeResult(ctx, name, inp)
+			}(input)
+		}
+	}()
+
+	// 按槽位顺序输出结果
+	go func() {
+		defer close(results)
+
+		for slot := range pending {
+			var result *core.LambdaResult[O]
+			select {
+			case result = <-slot:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// invokeResult 调用lambda并总是返回非空结果
+func (inv *Invoker[I, O]) invokeResult(ctx context.Context, name string, input I) *core.LambdaResult[O] {
+	result, err := inv.Invoke(ctx, name, input)
+	if result == nil {
+		result = errorResult[O](err)
+	}
+	return result
+}
+
+// errorResult 创建只包含错误的调用结果
+func errorResult[O any](err error) *core.LambdaResult[O] {
+	var zero O
+	return &core.LambdaResult[O]{
+		Output:    zero,
+		Error:     err,
+		Duration:  0,
+		Timestamp: time.Now(),
+	}
+}
+
+// MapReduce 并发地对每个输入调用lambda，再按输入顺序用 reducer 归约输出
+// 与 InvokeEach 共用同一套调度，并发数上限为 runtime.GOMAXPROCS(0)；
+// 任一调用失败时取消其余调用并返回该错误，取消后才开始的输入不会执行处理器。
+func MapReduce[I any, O any, R any](ctx context.Context, name string, inputs []I, reducer func(R, O) R, initial R) (R, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	inv := NewInvoker[I, O]()
+	outputs := make([]O, len(inputs))
+	var firstErr error
+	var once sync.Once
+
+	err := inv.invokeEach(ctx, name, inputs, runtime.GOMAXPROCS(0), func(idx int, result *core.LambdaResult[O]) {
+		if result.Error != nil {
+			once.Do(func() {
+				firstErr = fmt.Errorf("map failed at input %d: %w", idx, result.Error)
+				cancel()
+			})
+			return
+		}
+		outputs[idx] = result.Output
+	})
+	if err != nil {
+		return initial, err
+	}
+	if firstErr != nil {
+		return initial, firstErr
+	}
+
+	acc := initial
+	for _, output := range outputs {
+		acc = reducer(acc, output)
+	}
+	return acc, nil
+}
+
 // Pipeline 管道式调用多个lambda
 func (inv *Invoker[I, O]) Pipeline(ctx context.Context, name string, inputs []I) ([]*core.LambdaResult[O], error) {
 	results := make([]*core.LambdaResult[O], len(inputs))
@@ -221,6 +593,31 @@ func (inv *Invoker[I, O]) Retry(ctx context.Context, name string, input I, maxRe
 	}, lastErr
 }
 
+// InvokeEscalating 先调用快速lambda，失败后最多重试 fastRetries 次，仍失败则升级调用可靠lambda
+func (inv *Invoker[I, O]) InvokeEscalating(ctx context.Context, fastName, reliableName string, input I, fastRetries int) (*core.LambdaResult[O], error) {
+	var fastErr error
+
+	for attempt := 0; attempt <= fastRetries; attempt++ {
+		result, err := inv.Invoke(ctx, fastName, input)
+		if err == nil {
+			return result, nil
+		}
+		fastErr = err
+
+		// context 已结束，不再重试或升级
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+
+	result, err := inv.Invoke(ctx, reliableName, input)
+	if err != nil {
+		return result, fmt.Errorf("escalation to '%s' failed: %w (fast lambda '%s': %v)", reliableName, err, fastName, fastErr)
+	}
+
+	return result, nil
+}
+
 // Timeout 带超时的调用
 func (inv *Invoker[I, O]) Timeout(ctx context.Context, name string, input I, timeout time.Duration) (*core.LambdaResult[O], error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -250,7 +647,7 @@ func (inv *Invoker[I, O]) Batch(ctx context.Context, name string, inputs []I, ba
 		batch := inputs[i:end]
 
 		wg.Add(1)
-		go func(batch []I, startIndex int) {
+		core.Go(func() {
 			defer wg.Done()
 
 			batchResults := inv.InvokeMultiple(ctx, map[string]I{name: batch[0]}) // 简化处理
@@ -275,7 +672,7 @@ func (inv *Invoker[I, O]) Batch(ctx context.Context, name string, inputs []I, ba
 
 			// 添加结果到总结果
 			allResults = append(allResults, batchResults[name])
-		}(batch, i)
+		})
 	}
 
 	wg.Wait()
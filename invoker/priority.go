@@ -0,0 +1,116 @@
+package invoker
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// prioritySemaphore 按优先级放行等待者的信号量
+// 有空闲名额时直接获取；否则进入等待堆，名额释放时优先级高的等待者先获取，同优先级先到先得
+type prioritySemaphore struct {
+	mu       sync.Mutex
+	capacity int
+	inUse    int
+	seq      uint64
+	waiters  waiterHeap
+}
+
+// priorityWaiter 等待名额的调用
+type priorityWaiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	index    int // 在堆中的位置，-1 表示已获得名额
+}
+
+// waiterHeap 按优先级降序、到达顺序升序排列的最大堆
+type waiterHeap []*priorityWaiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x any) {
+	w := x.(*priorityWaiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
+
+// newPrioritySemaphore 创建优先级信号量
+func newPrioritySemaphore(capacity int) *prioritySemaphore {
+	return &prioritySemaphore{capacity: capacity}
+}
+
+// acquire 获取名额，ctx 结束时放弃等待并返回 ctx 的错误
+func (s *prioritySemaphore) acquire(ctx context.Context, priority int) error {
+	s.mu.Lock()
+	if s.inUse < s.capacity && s.waiters.Len() == 0 {
+		s.inUse++
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.seq++
+	waiter := &priorityWaiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.waiters, waiter)
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if waiter.index >= 0 {
+			heap.Remove(&s.waiters, waiter.index)
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+		s.mu.Unlock()
+
+		// 取消的同时已获得名额，交还给下一个等待者
+		s.release()
+		return ctx.Err()
+	}
+}
+
+// release 释放名额，有等待者时直接转交给优先级最高的等待者
+func (s *prioritySemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() > 0 {
+		waiter := heap.Pop(&s.waiters).(*priorityWaiter)
+		close(waiter.ready)
+		return
+	}
+	s.inUse--
+}
+
+// waiting 返回等待中的调用数
+func (s *prioritySemaphore) waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
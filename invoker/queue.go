@@ -0,0 +1,212 @@
+package invoker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// QueuedJob 队列中的一次调用，输入以 JSON 保存以便持久化
+type QueuedJob struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Payload  json.RawMessage `json:"payload"`
+	Attempts int             `json:"attempts"`
+}
+
+// Queue 调用队列，实现持久化存储即可在进程崩溃后继续处理
+// Dequeue 取出的任务在 Ack 之前视为处理中，未确认的任务应在重启后重新投递（至少一次语义）
+type Queue interface {
+	Enqueue(ctx context.Context, job QueuedJob) error
+	// Dequeue 阻塞直到有任务或 ctx 结束
+	Dequeue(ctx context.Context) (QueuedJob, error)
+	Ack(ctx context.Context, id string) error
+}
+
+// MemoryQueue 内存队列，主要用于测试
+type MemoryQueue struct {
+	mu       sync.Mutex
+	pending  []QueuedJob
+	inflight map[string]QueuedJob
+	notify   chan struct{}
+}
+
+// NewMemoryQueue 创建内存队列
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		inflight: make(map[string]QueuedJob),
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// Enqueue 追加任务
+func (q *MemoryQueue) Enqueue(ctx context.Context, job QueuedJob) error {
+	q.mu.Lock()
+	q.pending = append(q.pending, job)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Dequeue 取出最早的任务
+func (q *MemoryQueue) Dequeue(ctx context.Context) (QueuedJob, error) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			job := q.pending[0]
+			q.pending = q.pending[1:]
+			q.inflight[job.ID] = job
+			remaining := len(q.pending)
+			q.mu.Unlock()
+
+			// 还有任务时唤醒其它等待者
+			if remaining > 0 {
+				select {
+				case q.notify <- struct{}{}:
+				default:
+				}
+			}
+			return job, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return QueuedJob{}, ctx.Err()
+		}
+	}
+}
+
+// Ack 确认任务已处理
+func (q *MemoryQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, exists := q.inflight[id]; !exists {
+		return fmt.Errorf("job '%s' is not in flight", id)
+	}
+	delete(q.inflight, id)
+	return nil
+}
+
+// Recover 把所有未确认的任务放回队列，模拟重启后的重新投递
+func (q *MemoryQueue) Recover() int {
+	q.mu.Lock()
+	count := len(q.inflight)
+	for id, job := range q.inflight {
+		q.pending = append(q.pending, job)
+		delete(q.inflight, id)
+	}
+	q.mu.Unlock()
+
+	if count > 0 {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+	return count
+}
+
+// Len 返回待处理与处理中的任务数
+func (q *MemoryQueue) Len() (pending, inflight int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), len(q.inflight)
+}
+
+// newJobID 生成任务ID
+func newJobID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// WithQueue 设置 InvokeQueued 使用的队列
+func (inv *Invoker[I, O]) WithQueue(queue Queue) *Invoker[I, O] {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.queue = queue
+	return inv
+}
+
+// InvokeQueued 把调用放入队列，返回任务ID，由 RunQueueWorker 异步处理
+func (inv *Invoker[I, O]) InvokeQueued(ctx context.Context, name string, input I) (string, error) {
+	if inv.queue == nil {
+		return "", fmt.Errorf("invoker has no queue")
+	}
+	if _, exists := inv.Get(name); !exists {
+		return "", fmt.Errorf("%w: '%s'", core.ErrLambdaNotFound, name)
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("encode input for '%s': %w", name, err)
+	}
+
+	job := QueuedJob{ID: newJobID(), Name: name, Payload: payload}
+	if err := inv.queue.Enqueue(ctx, job); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// RunQueueWorker 持续处理队列中的任务直到 ctx 结束
+// 调用成功后确认任务；失败的任务在 maxAttempts 次以内重新入队，之后确认并丢弃。
+// 每个任务处理完成后以结果调用 onResult（可为 nil），最后一次失败时 err 非空。
+func (inv *Invoker[I, O]) RunQueueWorker(ctx context.Context, maxAttempts int, onResult func(job QueuedJob, result *core.LambdaResult[O], err error)) error {
+	if inv.queue == nil {
+		return fmt.Errorf("invoker has no queue")
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	for {
+		job, err := inv.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var input I
+		var result *core.LambdaResult[O]
+		if err = json.Unmarshal(job.Payload, &input); err == nil {
+			result, err = inv.Invoke(ctx, job.Name, input)
+		}
+
+		job.Attempts++
+		if err != nil && job.Attempts < maxAttempts {
+			// 先以新ID重新入队再确认旧的投递，中途崩溃最多导致重复处理而不会丢失
+			retry := job
+			retry.ID = newJobID()
+			if enqueueErr := inv.queue.Enqueue(ctx, retry); enqueueErr != nil {
+				return enqueueErr
+			}
+			if ackErr := inv.queue.Ack(ctx, job.ID); ackErr != nil {
+				return ackErr
+			}
+			continue
+		}
+
+		if ackErr := inv.queue.Ack(ctx, job.ID); ackErr != nil {
+			return ackErr
+		}
+		if onResult != nil {
+			onResult(job, result, err)
+		}
+	}
+}
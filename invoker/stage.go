@@ -0,0 +1,68 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// Stage 类型安全的调用步骤，把输入 A 转换为输出 B
+// 多个步骤通过 Pipe 组合为一个步骤，相邻步骤的类型在编译期检查
+type Stage[A any, B any] struct {
+	names []string
+	run   func(ctx context.Context, input A) (*core.LambdaResult[B], error)
+}
+
+// NewStage 创建调用已注册lambda的步骤
+func NewStage[A any, B any](inv *Invoker[A, B], name string) *Stage[A, B] {
+	return &Stage[A, B]{
+		names: []string{name},
+		run: func(ctx context.Context, input A) (*core.LambdaResult[B], error) {
+			return inv.Invoke(ctx, name, input)
+		},
+	}
+}
+
+// Pipe 组合两个步骤，first 的输出作为 second 的输入
+// Go 的方法不能声明新的类型参数，因此以函数形式提供：Pipe(Pipe(s1, s2), s3)
+func Pipe[A any, B any, C any](first *Stage[A, B], second *Stage[B, C]) *Stage[A, C] {
+	names := make([]string, 0, len(first.names)+len(second.names))
+	names = append(names, first.names...)
+	names = append(names, second.names...)
+
+	return &Stage[A, C]{
+		names: names,
+		run: func(ctx context.Context, input A) (*core.LambdaResult[C], error) {
+			intermediate, err := first.run(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+
+			result, err := second.run(ctx, intermediate.Output)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Duration += intermediate.Duration
+			result.Timestamp = intermediate.Timestamp
+			return result, nil
+		},
+	}
+}
+
+// Names 返回步骤包含的lambda名称，按执行顺序排列
+func (s *Stage[A, B]) Names() []string {
+	names := make([]string, len(s.names))
+	copy(names, s.names)
+	return names
+}
+
+// Invoke 执行步骤，Duration 为所有步骤耗时之和
+func (s *Stage[A, B]) Invoke(ctx context.Context, input A) (*core.LambdaResult[B], error) {
+	result, err := s.run(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("stage %v: %w", s.names, err)
+	}
+	return result, nil
+}
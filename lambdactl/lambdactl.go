@@ -0,0 +1,104 @@
+package lambdactl
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+// 退出码
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const usage = `usage:
+  lambdactl [--timeout duration] invoke <name>   从标准输入读取 JSON 负载并调用lambda
+  lambdactl --list                               列出所有已注册的lambda`
+
+// Run 执行命令行逻辑并返回退出码
+// 调用方需要先注册lambda（例如执行 registry.ExecuteAutoHandlers）
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lambdactl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprintln(stderr, usage) }
+
+	timeout := fs.Duration("timeout", 0, "调用超时时间，0 表示不限制")
+	list := fs.Bool("list", false, "列出所有已注册的lambda")
+
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if *list {
+		return runList(stdout)
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 || rest[0] != "invoke" {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	// 允许把选项写在lambda名称之后，但不接受多余的参数
+	if err := fs.Parse(rest[2:]); err != nil {
+		return ExitUsage
+	}
+	if extra := fs.Args(); len(extra) > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(extra, " "))
+		fs.Usage()
+		return ExitUsage
+	}
+
+	return runInvoke(rest[1], *timeout, stdin, stdout, stderr)
+}
+
+// runList 打印所有已注册lambda的元数据
+func runList(stdout io.Writer) int {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tINPUT\tOUTPUT\tCOMPONENT\tTAGS")
+	for _, meta := range registry.ListAll() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			meta.Name, meta.InputType, meta.OutputType, meta.ComponentType, strings.Join(meta.Tags, ","))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// runInvoke 读取 JSON 负载并调用lambda
+func runInvoke(name string, timeout time.Duration, stdin io.Reader, stdout, stderr io.Writer) int {
+	payload, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read payload: %v\n", err)
+		return ExitError
+	}
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		payload = []byte("null")
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	output, err := registry.InvokeJSON(ctx, name, payload)
+	if err != nil {
+		fmt.Fprintf(stderr, "invoke '%s' failed: %v\n", name, err)
+		return ExitError
+	}
+
+	fmt.Fprintln(stdout, string(output))
+	return ExitOK
+}
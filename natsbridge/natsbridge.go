@@ -0,0 +1,128 @@
+package natsbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/invoker"
+)
+
+// Msg 收到的消息，字段与 nats.Msg 一致
+type Msg struct {
+	Subject string
+	Reply   string
+	Data    []byte
+}
+
+// Subscription 订阅，*nats.Subscription 满足该接口
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Conn 桥接所需的连接操作
+// *nats.Conn 的 Subscribe 回调参数为 *nats.Msg，使用时包装一层即可：
+//
+//	type natsConn struct{ *nats.Conn }
+//
+//	func (c natsConn) Subscribe(subject string, handler func(*natsbridge.Msg)) (natsbridge.Subscription, error) {
+//	    return c.Conn.Subscribe(subject, func(m *nats.Msg) {
+//	        handler(&natsbridge.Msg{Subject: m.Subject, Reply: m.Reply, Data: m.Data})
+//	    })
+//	}
+type Conn interface {
+	Subscribe(subject string, handler func(msg *Msg)) (Subscription, error)
+	Publish(subject string, data []byte) error
+}
+
+// DeadLetter 发布到死信主题的消息内容
+type DeadLetter struct {
+	Subject string `json:"subject"`
+	Lambda  string `json:"lambda"`
+	Data    []byte `json:"data"`
+	Error   string `json:"error"`
+}
+
+// DeadLetterSubject 返回主题对应的默认死信主题
+func DeadLetterSubject(subject string) string {
+	return subject + ".dlq"
+}
+
+// Options 消费配置
+type Options struct {
+	// 死信主题，默认为 DeadLetterSubject(subject)
+	DeadLetterSubject string
+	// 每条消息调用使用的 context，默认为 context.Background
+	Context func() (context.Context, context.CancelFunc)
+}
+
+// Option 消费选项函数
+type Option func(*Options)
+
+// WithDeadLetterSubject 设置死信主题
+func WithDeadLetterSubject(subject string) Option {
+	return func(opts *Options) {
+		opts.DeadLetterSubject = subject
+	}
+}
+
+// WithMessageContext 设置每条消息调用使用的 context，例如为每次调用设置超时
+func WithMessageContext(newContext func() (context.Context, context.CancelFunc)) Option {
+	return func(opts *Options) {
+		opts.Context = newContext
+	}
+}
+
+// Consume 订阅主题，每条消息以 JSON 解码为 I 后调用名为 name 的lambda
+// replyWith 为 true 且消息带有回复主题时，以 JSON 编码的输出发布到回复主题。
+// 解码失败、调用失败或输出编码失败的消息以 DeadLetter 发布到死信主题。
+func Consume[I any, O any](nc Conn, subject, name string, replyWith bool, opts ...Option) (Subscription, error) {
+	options := &Options{
+		DeadLetterSubject: DeadLetterSubject(subject),
+		Context: func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	inv := invoker.NewInvoker[I, O]()
+
+	handle := func(msg *Msg) error {
+		var input I
+		if err := json.Unmarshal(msg.Data, &input); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+
+		ctx, cancel := options.Context()
+		defer cancel()
+
+		result, err := inv.Invoke(ctx, name, input)
+		if err != nil {
+			return fmt.Errorf("lambda '%s' failed: %w", name, err)
+		}
+
+		if !replyWith || msg.Reply == "" {
+			return nil
+		}
+
+		data, err := json.Marshal(result.Output)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		return nc.Publish(msg.Reply, data)
+	}
+
+	return nc.Subscribe(subject, func(msg *Msg) {
+		if err := handle(msg); err != nil {
+			deadLetter, _ := json.Marshal(DeadLetter{
+				Subject: msg.Subject,
+				Lambda:  name,
+				Data:    msg.Data,
+				Error:   err.Error(),
+			})
+			nc.Publish(options.DeadLetterSubject, deadLetter)
+		}
+	})
+}
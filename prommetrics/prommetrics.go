@@ -0,0 +1,152 @@
+package prommetrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// DefaultBuckets 默认的耗时直方图分桶（秒），与 Prometheus 客户端默认值一致
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultExporter 默认导出器
+var DefaultExporter = NewExporter()
+
+// Exporter 以 Prometheus 文本格式导出lambda指标
+type Exporter struct {
+	mu      sync.RWMutex
+	buckets []float64
+	series  map[string]*series
+}
+
+// series 单个lambda的指标
+type series struct {
+	invocations  int64
+	errors       int64
+	durationSum  float64
+	bucketCounts []int64
+}
+
+// NewExporter 创建导出器，未指定分桶时使用 DefaultBuckets
+func NewExporter(buckets ...float64) *Exporter {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Exporter{
+		buckets: sorted,
+		series:  make(map[string]*series),
+	}
+}
+
+// Observe 记录一次调用
+func (e *Exporter) Observe(name string, duration time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, exists := e.series[name]
+	if !exists {
+		s = &series{bucketCounts: make([]int64, len(e.buckets))}
+		e.series[name] = s
+	}
+
+	seconds := duration.Seconds()
+	s.invocations++
+	s.durationSum += seconds
+	if err != nil {
+		s.errors++
+	}
+	for i, bound := range e.buckets {
+		if seconds <= bound {
+			s.bucketCounts[i]++
+		}
+	}
+}
+
+// WriteTo 以 Prometheus 文本格式写出所有指标
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.series))
+	for name := range e.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	b.WriteString("# HELP minilambda_invocations_total Total number of lambda invocations.\n")
+	b.WriteString("# TYPE minilambda_invocations_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "minilambda_invocations_total{lambda=%s} %d\n", quote(name), e.series[name].invocations)
+	}
+
+	b.WriteString("# HELP minilambda_errors_total Total number of failed lambda invocations.\n")
+	b.WriteString("# TYPE minilambda_errors_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "minilambda_errors_total{lambda=%s} %d\n", quote(name), e.series[name].errors)
+	}
+
+	b.WriteString("# HELP minilambda_duration_seconds Lambda invocation duration in seconds.\n")
+	b.WriteString("# TYPE minilambda_duration_seconds histogram\n")
+	for _, name := range names {
+		s := e.series[name]
+		label := quote(name)
+		for i, bound := range e.buckets {
+			fmt.Fprintf(&b, "minilambda_duration_seconds_bucket{lambda=%s,le=\"%s\"} %d\n",
+				label, strconv.FormatFloat(bound, 'g', -1, 64), s.bucketCounts[i])
+		}
+		fmt.Fprintf(&b, "minilambda_duration_seconds_bucket{lambda=%s,le=\"+Inf\"} %d\n", label, s.invocations)
+		fmt.Fprintf(&b, "minilambda_duration_seconds_sum{lambda=%s} %s\n", label, strconv.FormatFloat(s.durationSum, 'g', -1, 64))
+		fmt.Fprintf(&b, "minilambda_duration_seconds_count{lambda=%s} %d\n", label, s.invocations)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler 返回暴露指标的 HTTP 处理器
+func (e *Exporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.WriteTo(w)
+	})
+}
+
+// Handler 返回默认导出器的 HTTP 处理器
+func Handler() http.Handler {
+	return DefaultExporter.Handler()
+}
+
+// PrometheusMetrics 指标中间件，记录到默认导出器
+func PrometheusMetrics[I any, O any](name string) core.Middleware[I, O] {
+	return Middleware[I, O](DefaultExporter, name)
+}
+
+// Middleware 指标中间件，记录到指定导出器
+func Middleware[I any, O any](exporter *Exporter, name string) core.Middleware[I, O] {
+	return func(ctx context.Context, input I, next core.InvokeFunc[I, O]) (O, error) {
+		start := time.Now()
+		output, err := next(ctx, input)
+		exporter.Observe(name, core.Since(start), err)
+		return output, err
+	}
+}
+
+// quote 转义标签值
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -1,9 +1,11 @@
 package registry
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/ZHLX2005/minilambda/core"
@@ -12,28 +14,67 @@ import (
 // AutoRegisterer 自动注册器
 type AutoRegisterer struct {
 	mu       sync.RWMutex
-	handlers []func()
+	handlers []autoHandler
+}
+
+// autoHandler 带优先级的自动处理函数，name 为空表示匿名
+type autoHandler struct {
+	name     string
+	priority int
+	handler  func()
 }
 
 var globalAutoRegisterer = &AutoRegisterer{}
 
-// RegisterHandler 注册自动处理函数
+// RegisterHandler 注册匿名的自动处理函数，优先级为 0
 func (ar *AutoRegisterer) RegisterHandler(handler func()) {
+	ar.RegisterHandlerWithPriority("", 0, handler)
+}
+
+// RegisterHandlerWithPriority 注册带优先级的自动处理函数
+// 数值越小越先执行，优先级相同时按注册顺序执行；
+// 被其他lambda依赖的注册函数应使用更小的优先级。
+// name 非空时必须唯一，重复时返回错误；处理函数 panic 时会带上 name 与优先级重新 panic。
+func (ar *AutoRegisterer) RegisterHandlerWithPriority(name string, priority int, handler func()) error {
 	ar.mu.Lock()
 	defer ar.mu.Unlock()
-	ar.handlers = append(ar.handlers, handler)
+
+	if name != "" {
+		for _, h := range ar.handlers {
+			if h.name == name {
+				return fmt.Errorf("auto handler '%s' already registered", name)
+			}
+		}
+	}
+	ar.handlers = append(ar.handlers, autoHandler{name: name, priority: priority, handler: handler})
+	return nil
 }
 
-// ExecuteHandlers 执行所有处理函数
+// ExecuteHandlers 按优先级执行所有处理函数
 func (ar *AutoRegisterer) ExecuteHandlers() {
 	ar.mu.RLock()
-	handlers := make([]func(), len(ar.handlers))
-	copy(handlers, ar.handlers)
+	handlers := slices.Clone(ar.handlers)
 	ar.mu.RUnlock()
 
-	for _, handler := range handlers {
-		handler()
+	slices.SortStableFunc(handlers, func(a, b autoHandler) int {
+		return cmp.Compare(a.priority, b.priority)
+	})
+
+	for _, h := range handlers {
+		h.run()
+	}
+}
+
+// run 执行处理函数，具名处理函数 panic 时补充名称与优先级
+func (h autoHandler) run() {
+	if h.name != "" {
+		defer func() {
+			if r := recover(); r != nil {
+				panic(fmt.Sprintf("auto handler '%s' (priority %d) panicked: %v", h.name, h.priority, r))
+			}
+		}()
 	}
+	h.handler()
 }
 
 // RegisterAutoHandler 注册自动处理函数到全局注册器
@@ -41,7 +82,14 @@ func RegisterAutoHandler(handler func()) {
 	globalAutoRegisterer.RegisterHandler(handler)
 }
 
-// ExecuteAutoHandlers 执行所有自动处理函数
+// RegisterWithPriority 注册带优先级的自动处理函数到全局注册器
+// ExecuteAutoHandlers 按 priority 从小到大执行；name 用于去重与错误报告，重复时返回错误。
+// 本包没有 WarmupAll，预热（如 Broadcast）发生在注册完成之后，不依赖注册顺序。
+func RegisterWithPriority(name string, priority int, handler func()) error {
+	return globalAutoRegisterer.RegisterHandlerWithPriority(name, priority, handler)
+}
+
+// ExecuteAutoHandlers 按优先级执行所有自动处理函数
 func ExecuteAutoHandlers() {
 	globalAutoRegisterer.ExecuteHandlers()
 }
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// LambdaDef 批量注册中的一个lambda定义
+type LambdaDef[I any, O any] struct {
+	Name    string
+	Invoke  core.InvokeFunc[I, O]
+	Options []core.LambdaOption
+}
+
+// BatchOptions 批量注册配置
+type BatchOptions struct {
+	// 同名lambda已存在时替换而不是报错
+	Replace bool
+	// 是否全部成功或全部不注册，默认开启
+	Atomic bool
+}
+
+// BatchOption 批量注册选项函数
+type BatchOption func(*BatchOptions)
+
+// WithBatchReplace 已存在的同名lambda被替换而不是视为失败
+func WithBatchReplace() BatchOption {
+	return func(opts *BatchOptions) {
+		opts.Replace = true
+	}
+}
+
+// WithBatchBestEffort 关闭原子模式：注册所有有效的定义，只跳过失败的定义
+func WithBatchBestEffort() BatchOption {
+	return func(opts *BatchOptions) {
+		opts.Atomic = false
+	}
+}
+
+// RegisterBatch 批量注册lambda到全局注册表
+// 默认是原子的：任一定义失败（名称为空、处理器为空、与已注册或同批次的lambda重名）时不注册任何定义。
+// 返回的错误由 errors.Join 合并，逐个说明失败的名称。
+func RegisterBatch[I any, O any](defs []LambdaDef[I, O], opts ...BatchOption) error {
+	options := &BatchOptions{Atomic: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return getRegistry[I, O]().registerBatch(defs, options)
+}
+
+// registerBatch 在持有写锁的情况下校验并注册所有定义
+func (r *Registry[I, O]) registerBatch(defs []LambdaDef[I, O], options *BatchOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	valid := make([]LambdaDef[I, O], 0, len(defs))
+	seen := make(map[string]bool, len(defs))
+
+	for _, def := range defs {
+		switch {
+		case def.Name == "":
+			errs = append(errs, errors.New("lambda name is required"))
+			continue
+		case def.Invoke == nil:
+			errs = append(errs, fmt.Errorf("lambda '%s' has no invoke function", def.Name))
+			continue
+		case seen[def.Name]:
+			errs = append(errs, fmt.Errorf("lambda '%s' defined more than once in batch", def.Name))
+			continue
+		}
+		seen[def.Name] = true
+
+		if _, exists := r.lambdas[def.Name]; exists && !options.Replace {
+			errs = append(errs, fmt.Errorf("lambda '%s' already registered", def.Name))
+			continue
+		}
+		valid = append(valid, def)
+	}
+
+	if len(errs) > 0 && options.Atomic {
+		return fmt.Errorf("batch registration rolled back: %w", errors.Join(errs...))
+	}
+
+	for _, def := range valid {
+		lambda := core.NewLambda(def.Name, def.Invoke, def.Options...)
+		r.lambdas[def.Name] = lambda
+		r.meta[def.Name] = registryMeta(lambda)
+		emit(Registered, r.meta[def.Name])
+	}
+
+	return errors.Join(errs...)
+}
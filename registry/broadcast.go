@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// broadcaster 在不知道泛型参数的情况下向注册表广播调用
+type broadcaster interface {
+	broadcast(ctx context.Context, tag string) map[string]error
+}
+
+// SetDefaultInput 设置lambda的默认输入，供 Broadcast 使用
+func (r *Registry[I, O]) SetDefaultInput(name string, input I) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaults[name] = input
+}
+
+// broadcast 以默认输入调用带有指定标签的lambda
+func (r *Registry[I, O]) broadcast(ctx context.Context, tag string) map[string]error {
+	sweepRemovedVersions()
+
+	type target struct {
+		lambda *core.Lambda[I, O]
+		input  I
+	}
+
+	r.mu.RLock()
+	var targets []target
+	for name, lambda := range r.lambdas {
+		input, hasDefault := r.defaults[name]
+		if hasDefault && hasTag(r.meta[name], tag) {
+			targets = append(targets, target{lambda: lambda, input: input})
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make(map[string]error, len(targets))
+	for _, t := range targets {
+		_, err := t.lambda.Invoke(ctx, t.input)
+		results[t.lambda.GetName()] = err
+	}
+
+	return results
+}
+
+// SetDefaultInput 为全局注册表中的lambda设置默认输入
+func SetDefaultInput[I any, O any](name string, input I) {
+	reg := getRegistry[I, O]()
+	reg.SetDefaultInput(name, input)
+}
+
+// Broadcast 以默认输入调用所有带有 "tagKey=tagValue" 标签的lambda
+// 没有默认输入的lambda会被跳过，返回值以lambda名称为键记录每次调用的错误
+func Broadcast(ctx context.Context, tagKey, tagValue string) map[string]error {
+	tag := tagKey + "=" + tagValue
+	results := make(map[string]error)
+
+	globalRegistries.Range(func(_, value any) bool {
+		if b, ok := value.(broadcaster); ok {
+			for name, err := range b.broadcast(ctx, tag) {
+				results[name] = err
+			}
+		}
+		return true
+	})
+
+	return results
+}
@@ -0,0 +1,51 @@
+package registry
+
+import (
+	"context"
+)
+
+// DiffResult 两个lambda在同一输入上的差异
+type DiffResult[I any, O any] struct {
+	Index   int
+	Input   I
+	OutputA O
+	OutputB O
+	ErrA    error
+	ErrB    error
+}
+
+// CompareLambdas 用同一组输入分别调用两个lambda，返回结果不一致的输入
+// 两边都出错且错误信息相同时视为一致
+func CompareLambdas[I any, O any](ctx context.Context, nameA, nameB string, inputs []I, equal func(O, O) bool) []DiffResult[I, O] {
+	var diffs []DiffResult[I, O]
+
+	for i, input := range inputs {
+		diff := DiffResult[I, O]{Index: i, Input: input}
+		diff.OutputA, diff.ErrA = invokeByName[I, O](ctx, nameA, input)
+		diff.OutputB, diff.ErrB = invokeByName[I, O](ctx, nameB, input)
+
+		if !sameOutcome(diff, equal) {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs
+}
+
+// invokeByName 从全局注册表查找并调用lambda
+func invokeByName[I any, O any](ctx context.Context, name string, input I) (O, error) {
+	result, err := Invoke[I, O](ctx, name, input)
+	if result == nil {
+		var zero O
+		return zero, err
+	}
+	return result.Output, err
+}
+
+// sameOutcome 判断两次调用结果是否一致
+func sameOutcome[I any, O any](diff DiffResult[I, O], equal func(O, O) bool) bool {
+	if diff.ErrA != nil || diff.ErrB != nil {
+		return diff.ErrA != nil && diff.ErrB != nil && diff.ErrA.Error() == diff.ErrB.Error()
+	}
+	return equal(diff.OutputA, diff.OutputB)
+}
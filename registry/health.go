@@ -0,0 +1,69 @@
+package registry
+
+// healthReporter 在不知道泛型参数的情况下统计注册表中的lambda
+type healthReporter interface {
+	healthStats() (int, map[string]int)
+}
+
+// healthStats 在一次加锁中统计lambda总数及各命名空间的数量
+// 已注册的lambda按元数据中的命名空间计数；尚未构建的构造函数没有元数据，计入默认命名空间。
+// 已构建的构造函数只按lambda计一次，与 List 一致。
+func (r *Registry[I, O]) healthStats() (int, map[string]int) {
+	sweepRemovedVersions()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	namespaces := make(map[string]int)
+	for _, meta := range r.meta {
+		namespaces[meta.Namespace]++
+	}
+	total := len(r.meta)
+	for name := range r.constructors {
+		if _, exists := r.lambdas[name]; !exists {
+			namespaces[""]++
+			total++
+		}
+	}
+	return total, namespaces
+}
+
+// RegistryHealth 所有类型注册表的汇总状态
+type RegistryHealth struct {
+	// 所有类型注册表中的lambda总数（含尚未构建的构造函数），等于 Namespaces 各项之和
+	TotalLambdas int
+	// 至少包含一个lambda的输入/输出类型组合数
+	TypeCombinations int
+	// 各命名空间中的lambda数量，默认命名空间的键为 ""
+	Namespaces map[string]int
+}
+
+// GlobalCount 返回所有类型注册表中的lambda总数，与 Health().TotalLambdas 的口径一致
+func GlobalCount() int {
+	return Health().TotalLambdas
+}
+
+// Health 汇总所有类型注册表的状态
+// 每个类型注册表的总数与命名空间计数取自同一快照。
+func Health() RegistryHealth {
+	health := RegistryHealth{Namespaces: make(map[string]int)}
+
+	globalRegistries.Range(func(_, value any) bool {
+		reporter, ok := value.(healthReporter)
+		if !ok {
+			return true
+		}
+
+		total, namespaces := reporter.healthStats()
+		if total > 0 {
+			health.TotalLambdas += total
+			health.TypeCombinations++
+		}
+		for namespace, count := range namespaces {
+			health.Namespaces[namespace] += count
+		}
+		return true
+	})
+
+	return health
+}
@@ -0,0 +1,30 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// Invoke 按名称从全局注册表查找类型为 I -> O 的lambda并调用
+// 等价于不带任何选项的 invoker.NewInvoker[I, O]().Invoke；
+// 不经过调用器层的命名空间、自适应超时、并发限制与优雅关闭，需要这些功能时应使用 invoker.Invoker。
+func Invoke[I any, O any](ctx context.Context, name string, input I) (*core.LambdaResult[O], error) {
+	lambda, exists := GetLambda[I, O](name)
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", core.ErrLambdaNotFound, name)
+	}
+
+	return lambda.Invoke(ctx, input)
+}
+
+// MustInvoke 调用lambda并返回输出，lambda 不存在或调用失败时 panic
+// 适用于初始化代码与测试等失败即为程序错误的场景
+func MustInvoke[I any, O any](ctx context.Context, name string, input I) O {
+	result, err := Invoke[I, O](ctx, name, input)
+	if err != nil {
+		panic(fmt.Sprintf("registry: invoke lambda '%s': %v", name, err))
+	}
+	return result.Output
+}
@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+var (
+	// ErrLambdaNotFound 未找到lambda，与 core.ErrLambdaNotFound 相同
+	ErrLambdaNotFound = core.ErrLambdaNotFound
+	// ErrInvalidPayload 输入无法解码为lambda的输入类型
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// jsonInvoker 在不知道泛型参数的情况下以 JSON 调用lambda
+type jsonInvoker interface {
+	invokeJSON(ctx context.Context, name string, payload []byte) ([]byte, bool, error)
+}
+
+// invokeJSON 解码输入、调用lambda并编码输出，lambda 不存在时返回 false
+func (r *Registry[I, O]) invokeJSON(ctx context.Context, name string, payload []byte) ([]byte, bool, error) {
+	lambda, exists := r.Get(name)
+	if !exists {
+		return nil, false, nil
+	}
+
+	input, err := decodeJSONInput[I](payload)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	result, err := lambda.Invoke(ctx, input)
+	if err != nil {
+		return nil, true, err
+	}
+
+	output, err := json.Marshal(result.Output)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to encode output of lambda '%s': %w", name, err)
+	}
+
+	return output, true, nil
+}
+
+// InvokeJSON 按名称在所有类型组合中查找lambda，以 JSON 编码的输入调用并返回 JSON 编码的输出
+// 同名lambda存在于多个类型组合时，按类型键排序取第一个
+func InvokeJSON(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	var keys []string
+	invokers := make(map[string]jsonInvoker)
+
+	globalRegistries.Range(func(key, value any) bool {
+		if inv, ok := value.(jsonInvoker); ok {
+			keys = append(keys, key.(string))
+			invokers[key.(string)] = inv
+		}
+		return true
+	})
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		output, found, err := invokers[key].invokeJSON(ctx, name, payload)
+		if found {
+			return output, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrLambdaNotFound, name)
+}
+
+// decodeJSONInput 按lambda的输入类型解码 JSON
+// 数字以 json.Number 解码，避免经 float64 中转丢失精度：
+//   - 输入类型为整数时，接受数值为整数的写法（如 2.0、1e3），超出范围或带小数部分时报错
+//   - any 类型的位置（包括嵌套的 map、切片和结构体字段）中，整数转换为 int64，其余数字转换为 float64
+func decodeJSONInput[I any](payload []byte) (I, error) {
+	var input I
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	err := decoder.Decode(&input)
+	if err == nil {
+		if _, trailing := decoder.Token(); trailing != io.EOF {
+			return input, errors.New("unexpected data after top-level value")
+		}
+		normalizeNumbers(reflect.ValueOf(&input).Elem())
+		return input, nil
+	}
+
+	// 整数类型的输入：尝试把数值为整数的 JSON 数字强制转换
+	target := reflect.ValueOf(&input).Elem()
+	var number json.Number
+	if isIntegerKind(target.Kind()) && json.Unmarshal(payload, &number) == nil {
+		if coerceErr := setInteger(target, number); coerceErr != nil {
+			return input, coerceErr
+		}
+		return input, nil
+	}
+
+	return input, err
+}
+
+// isIntegerKind 判断是否为整数类型
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// setInteger 将数值为整数的 JSON 数字写入整数类型的 target
+func setInteger(target reflect.Value, number json.Number) error {
+	value, _, err := big.ParseFloat(number.String(), 10, 0, big.ToNearestEven)
+	if err != nil || !value.IsInt() {
+		return fmt.Errorf("cannot convert number %s to %s", number, target.Type())
+	}
+
+	integer, _ := value.Int(nil)
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !integer.IsInt64() || target.OverflowInt(integer.Int64()) {
+			return fmt.Errorf("number %s overflows %s", number, target.Type())
+		}
+		target.SetInt(integer.Int64())
+	default:
+		if !integer.IsUint64() || target.OverflowUint(integer.Uint64()) {
+			return fmt.Errorf("number %s overflows %s", number, target.Type())
+		}
+		target.SetUint(integer.Uint64())
+	}
+	return nil
+}
+
+// normalizeNumbers 把 any 类型位置中的 json.Number 转换为 int64 或 float64
+func normalizeNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if number, ok := v.Interface().(json.Number); ok {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(numberValue(number)))
+			}
+			return
+		}
+		// 接口中的值不可寻址，复制后处理再写回
+		elem := v.Elem()
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		normalizeNumbers(copied)
+		if v.CanSet() {
+			v.Set(copied)
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			normalizeNumbers(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				normalizeNumbers(field)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			normalizeNumbers(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			normalizeNumbers(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+}
+
+// numberValue 整数返回 int64，其余返回 float64
+func numberValue(number json.Number) any {
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+	return number.String()
+}
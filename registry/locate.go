@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// LambdaDescriptor 类型擦除的lambda描述，供不知道具体类型的编排代码调用
+type LambdaDescriptor struct {
+	Meta       core.LambdaMeta
+	InputType  reflect.Type
+	OutputType reflect.Type
+	// Invoke 以 any 传入输入并返回输出，输入的动态类型必须与 InputType 一致
+	Invoke func(ctx context.Context, input any) (any, error)
+}
+
+// locator 在不知道泛型参数的情况下按名称生成lambda描述
+type locator interface {
+	locate(name string) (*LambdaDescriptor, bool)
+}
+
+// locate 生成指定lambda的描述，lambda 不存在时返回 false
+func (r *Registry[I, O]) locate(name string) (*LambdaDescriptor, bool) {
+	sweepRemovedVersions()
+
+	r.mu.RLock()
+	lambda, exists := r.lambdas[name]
+	meta := r.meta[name]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, false
+	}
+
+	return &LambdaDescriptor{
+		Meta:       meta,
+		InputType:  reflect.TypeOf((*I)(nil)).Elem(),
+		OutputType: reflect.TypeOf((*O)(nil)).Elem(),
+		Invoke: func(ctx context.Context, input any) (any, error) {
+			typed, ok := input.(I)
+			if !ok && input != nil {
+				return nil, fmt.Errorf("%w: lambda '%s' expects %s, got %T", ErrInvalidPayload, name, meta.InputType, input)
+			}
+
+			result, err := lambda.Invoke(ctx, typed)
+			if err != nil {
+				return nil, err
+			}
+			return result.Output, nil
+		},
+	}, true
+}
+
+// Locate 按名称在所有类型组合中查找lambda并返回其描述
+// 同名lambda存在于多个类型组合时，按类型键排序取第一个（与 InvokeJSON 一致）
+func Locate(name string) (*LambdaDescriptor, bool) {
+	var keys []string
+	locators := make(map[string]locator)
+
+	globalRegistries.Range(func(key, value any) bool {
+		if l, ok := value.(locator); ok {
+			keys = append(keys, key.(string))
+			locators[key.(string)] = l
+		}
+		return true
+	})
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if descriptor, found := locators[key].locate(name); found {
+			return descriptor, true
+		}
+	}
+
+	return nil, false
+}
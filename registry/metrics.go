@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// MetricsSnapshot 可持久化的lambda指标快照
+type MetricsSnapshot struct {
+	Registry           string        `json:"registry"`
+	Name               string        `json:"name"`
+	TotalInvocations   int64         `json:"total_invocations"`
+	SuccessInvocations int64         `json:"success_invocations"`
+	ErrorInvocations   int64         `json:"error_invocations"`
+	TimeoutInvocations int64         `json:"timeout_invocations,omitempty"`
+	RetryInvocations   int64         `json:"retry_invocations,omitempty"`
+	TotalRetries       int64         `json:"total_retries,omitempty"`
+	TotalDuration      time.Duration `json:"total_duration"`
+	SumSquares         float64       `json:"sum_squares,omitempty"`
+	LastInvocationTime time.Time     `json:"last_invocation_time"`
+}
+
+// metricsStore 在不知道泛型参数的情况下读写注册表中lambda的指标
+type metricsStore interface {
+	snapshotMetrics(key string) []MetricsSnapshot
+	restoreMetrics(snapshot MetricsSnapshot) bool
+}
+
+// snapshotMetrics 返回注册表中所有lambda的指标快照
+func (r *Registry[I, O]) snapshotMetrics(key string) []MetricsSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := make([]MetricsSnapshot, 0, len(r.lambdas))
+	for name, lambda := range r.lambdas {
+		metrics := lambda.GetMetrics()
+		snapshots = append(snapshots, MetricsSnapshot{
+			Registry:           key,
+			Name:               name,
+			TotalInvocations:   metrics.TotalInvocations,
+			SuccessInvocations: metrics.SuccessInvocations,
+			ErrorInvocations:   metrics.ErrorInvocations,
+			TimeoutInvocations: metrics.TimeoutInvocations,
+			RetryInvocations:   metrics.RetryInvocations,
+			TotalRetries:       metrics.TotalRetries,
+			TotalDuration:      metrics.TotalDuration,
+			SumSquares:         metrics.SumSquares,
+			LastInvocationTime: metrics.LastInvocationTime,
+		})
+	}
+
+	return snapshots
+}
+
+// restoreMetrics 将快照累加到同名lambda的指标上，lambda 不存在时返回 false
+func (r *Registry[I, O]) restoreMetrics(snapshot MetricsSnapshot) bool {
+	lambda, exists := r.Get(snapshot.Name)
+	if !exists {
+		return false
+	}
+
+	lambda.MergeMetrics(&core.LambdaMetrics{
+		TotalInvocations:   snapshot.TotalInvocations,
+		SuccessInvocations: snapshot.SuccessInvocations,
+		ErrorInvocations:   snapshot.ErrorInvocations,
+		TimeoutInvocations: snapshot.TimeoutInvocations,
+		RetryInvocations:   snapshot.RetryInvocations,
+		TotalRetries:       snapshot.TotalRetries,
+		TotalDuration:      snapshot.TotalDuration,
+		SumSquares:         snapshot.SumSquares,
+		LastInvocationTime: snapshot.LastInvocationTime,
+	})
+	return true
+}
+
+// SaveMetrics 以 JSON 格式写出所有已注册lambda的指标计数
+func SaveMetrics(w io.Writer) error {
+	var snapshots []MetricsSnapshot
+
+	globalRegistries.Range(func(key, value any) bool {
+		if store, ok := value.(metricsStore); ok {
+			snapshots = append(snapshots, store.snapshotMetrics(key.(string))...)
+		}
+		return true
+	})
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Registry != snapshots[j].Registry {
+			return snapshots[i].Registry < snapshots[j].Registry
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshots)
+}
+
+// LoadMetrics 读取 SaveMetrics 写出的指标并累加到对应lambda上
+// 找不到对应lambda的快照会被忽略
+func LoadMetrics(r io.Reader) error {
+	var snapshots []MetricsSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshots); err != nil {
+		return fmt.Errorf("failed to decode metrics: %w", err)
+	}
+
+	for _, snapshot := range snapshots {
+		value, ok := globalRegistries.Load(snapshot.Registry)
+		if !ok {
+			continue
+		}
+		if store, ok := value.(metricsStore); ok {
+			store.restoreMetrics(snapshot)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// namespaceSeparator 命名空间与lambda名称之间的分隔符
+const namespaceSeparator = "/"
+
+// QualifiedName 返回命名空间内lambda在注册中心中的完整名称，namespace 为空时返回 name
+func QualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + namespaceSeparator + name
+}
+
+// namespaceConfigKey 记录lambda所属命名空间的配置键，仅由 RegisterNamespacedLambda 设置
+// 命名空间不从名称中解析，因此普通名称中包含分隔符的lambda不会被误认为带命名空间。
+type namespaceConfigKey struct{}
+
+// ValidateNamespacePart 检查命名空间或其中的lambda名称是否可用于 QualifiedName
+// 两者都不能包含分隔符，否则 ("a", "b/c") 与 ("a/b", "c") 会映射到同一个完整名称。
+func ValidateNamespacePart(part string) error {
+	if strings.Contains(part, namespaceSeparator) {
+		return fmt.Errorf("namespace part '%s' must not contain '%s'", part, namespaceSeparator)
+	}
+	return nil
+}
+
+// registryMeta 生成注册中心保存的元数据，命名空间内的lambda把命名空间拆分到 Namespace 字段
+func registryMeta[I any, O any](lambda *core.Lambda[I, O]) core.LambdaMeta {
+	meta := lambda.GetMeta()
+	if namespace, ok := lambda.Config(namespaceConfigKey{}); ok {
+		meta.Namespace = namespace.(string)
+		meta.Name = strings.TrimPrefix(meta.Name, meta.Namespace+namespaceSeparator)
+	}
+	return meta
+}
+
+// RegisterNamespacedLambda 在命名空间内注册lambda，不同命名空间中的同名lambda互不冲突
+// namespace 与 name 都不能包含分隔符 "/"。
+func RegisterNamespacedLambda[I any, O any](namespace, name string, invoke core.InvokeFunc[I, O], opts ...core.LambdaOption) error {
+	for _, part := range []string{namespace, name} {
+		if err := ValidateNamespacePart(part); err != nil {
+			return err
+		}
+	}
+	if namespace != "" {
+		opts = append(slices.Clip(opts), core.WithConfig(namespaceConfigKey{}, namespace))
+	}
+	return RegisterLambda(QualifiedName(namespace, name), invoke, opts...)
+}
+
+// GetNamespacedLambda 获取命名空间内的lambda
+func GetNamespacedLambda[I any, O any](namespace, name string) (*core.Lambda[I, O], bool) {
+	if ValidateNamespacePart(namespace) != nil || ValidateNamespacePart(name) != nil {
+		return nil, false
+	}
+	return GetLambda[I, O](QualifiedName(namespace, name))
+}
+
+// ListNamespace 列出命名空间内所有lambda的元数据
+func ListNamespace(namespace string) []core.LambdaMeta {
+	var metas []core.LambdaMeta
+	for _, meta := range ListAll() {
+		if meta.Namespace == namespace {
+			metas = append(metas, meta)
+		}
+	}
+	return metas
+}
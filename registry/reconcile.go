@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// LambdaConfig 期望的lambda配置，由 NewLambdaConfig 创建
+type LambdaConfig struct {
+	Name string
+	ops  reconcileOps
+}
+
+// reconcileOps 在不知道泛型参数的情况下操作注册表
+type reconcileOps interface {
+	typeKey() string
+	prepare()
+	add() error
+	options(name string) (*core.LambdaOptions, bool)
+	desiredOptions() *core.LambdaOptions
+	update(name string, opts *core.LambdaOptions) bool
+	remove(name string) bool
+}
+
+// typedConfig 带类型的配置操作
+type typedConfig[I any, O any] struct {
+	name        string
+	constructor func() *core.Lambda[I, O]
+	built       *core.Lambda[I, O]
+}
+
+// NewLambdaConfig 创建期望的lambda配置
+// constructor 创建带有期望选项的lambda，Reconcile 用它注册缺失的lambda并比较选项
+func NewLambdaConfig[I any, O any](name string, constructor func() *core.Lambda[I, O]) LambdaConfig {
+	return LambdaConfig{
+		Name: name,
+		ops:  &typedConfig[I, O]{name: name, constructor: constructor},
+	}
+}
+
+func (c *typedConfig[I, O]) typeKey() string { return registryKey[I, O]() }
+
+// prepare 构造期望的lambda，每次 Reconcile 构造一次
+func (c *typedConfig[I, O]) prepare() {
+	c.built = c.constructor()
+}
+
+func (c *typedConfig[I, O]) add() error {
+	lambda := c.built
+	if lambda.GetName() != c.name {
+		return fmt.Errorf("constructor for '%s' returned lambda named '%s'", c.name, lambda.GetName())
+	}
+	return getRegistry[I, O]().Register(lambda)
+}
+
+func (c *typedConfig[I, O]) options(name string) (*core.LambdaOptions, bool) {
+	lambda, exists := getRegistry[I, O]().Get(name)
+	if !exists {
+		return nil, false
+	}
+	return lambda.GetOptions(), true
+}
+
+func (c *typedConfig[I, O]) desiredOptions() *core.LambdaOptions {
+	return c.built.GetOptions()
+}
+
+// update 以期望选项替换已注册lambda的选项，保留处理函数与指标
+func (c *typedConfig[I, O]) update(name string, opts *core.LambdaOptions) bool {
+	reg := getRegistry[I, O]()
+	lambda, exists := reg.Get(name)
+	if !exists {
+		return false
+	}
+	reg.Replace(lambda.WithOptions(func(o *core.LambdaOptions) { *o = *opts }))
+	return true
+}
+
+func (c *typedConfig[I, O]) remove(name string) bool {
+	return getRegistry[I, O]().Unregister(name)
+}
+
+// ReconcileResult Reconcile 的变更记录，各列表按名称排序
+type ReconcileResult struct {
+	Added     []string
+	Removed   []string
+	Updated   []string
+	Unchanged []string
+	Errors    map[string]error
+}
+
+// reconciler 记录由 Reconcile 管理的lambda
+type reconciler struct {
+	mu      sync.Mutex
+	managed map[string]managedLambda
+}
+
+// managedLambda 受管理的lambda
+type managedLambda struct {
+	name string
+	ops  reconcileOps
+}
+
+var globalReconciler = &reconciler{managed: make(map[string]managedLambda)}
+
+// managedKey 以类型和名称区分受管理的lambda
+func managedKey(typeKey, name string) string {
+	return typeKey + " " + name
+}
+
+// Reconcile 使注册中心与期望配置一致
+// 缺失的lambda通过构造函数注册，选项变化的lambda原地更新选项（保留处理函数与指标），
+// 之前由 Reconcile 注册但不再出现在期望配置中的lambda会被注销。
+// 不是由 Reconcile 注册的lambda即使出现在期望配置中也只会更新选项，之后不会被注销。
+func Reconcile(desired []LambdaConfig) ReconcileResult {
+	globalReconciler.mu.Lock()
+	defer globalReconciler.mu.Unlock()
+
+	result := ReconcileResult{Errors: make(map[string]error)}
+	seen := make(map[string]bool)
+
+	for _, cfg := range desired {
+		key := managedKey(cfg.ops.typeKey(), cfg.Name)
+		seen[key] = true
+		cfg.ops.prepare()
+
+		_, owned := globalReconciler.managed[key]
+		current, exists := cfg.ops.options(cfg.Name)
+		switch {
+		case !exists:
+			if err := cfg.ops.add(); err != nil {
+				result.Errors[cfg.Name] = err
+				continue
+			}
+			owned = true
+			result.Added = append(result.Added, cfg.Name)
+		case optionsEqual(current, cfg.ops.desiredOptions()):
+			result.Unchanged = append(result.Unchanged, cfg.Name)
+		default:
+			cfg.ops.update(cfg.Name, cfg.ops.desiredOptions())
+			result.Updated = append(result.Updated, cfg.Name)
+		}
+
+		// 只接管由 Reconcile 注册的lambda，已存在的lambda只更新选项
+		if owned {
+			globalReconciler.managed[key] = managedLambda{name: cfg.Name, ops: cfg.ops}
+		}
+	}
+
+	for key, managed := range globalReconciler.managed {
+		if seen[key] {
+			continue
+		}
+		if managed.ops.remove(managed.name) {
+			result.Removed = append(result.Removed, managed.name)
+		}
+		delete(globalReconciler.managed, key)
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Unchanged)
+	return result
+}
+
+// optionsEqual 比较可声明的选项，钩子等函数值不参与比较
+func optionsEqual(a, b *core.LambdaOptions) bool {
+	return a.Timeout == b.Timeout &&
+		a.EnableMetrics == b.EnableMetrics &&
+		a.Concurrency == b.Concurrency &&
+		a.Retries == b.Retries &&
+		a.EnableCallback == b.EnableCallback &&
+		a.ComponentType == b.ComponentType &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Recover == b.Recover &&
+		a.IsolatedExecution == b.IsolatedExecution &&
+		configEqual(a.Config, b.Config)
+}
+
+// configEqual 比较配置项，值可能不可比较（如切片），因此使用 reflect.DeepEqual
+func configEqual(a, b map[any]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
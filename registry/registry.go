@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/ZHLX2005/minilambda/core"
 	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -16,17 +17,24 @@ type Registry[I any, O any] struct {
 	lambdas      map[string]*core.Lambda[I, O]
 	constructors map[string]func() *core.Lambda[I, O]
 	meta         map[string]core.LambdaMeta
+	defaults     map[string]I
 }
 
 // globalRegistries 存储所有泛型类型组合的注册表
 var globalRegistries = sync.Map{}
 
+// metaLister 在不知道泛型参数的情况下读取注册表中的元数据
+type metaLister interface {
+	listMeta() []core.LambdaMeta
+}
+
 // NewRegistry 创建新的注册中心
 func NewRegistry() *Registry[string, string] {
 	return &Registry[string, string]{
 		lambdas:      make(map[string]*core.Lambda[string, string]),
 		constructors: make(map[string]func() *core.Lambda[string, string]),
 		meta:         make(map[string]core.LambdaMeta),
+		defaults:     make(map[string]string),
 	}
 }
 
@@ -42,6 +50,7 @@ func getRegistry[I any, O any]() *Registry[I, O] {
 		lambdas:      make(map[string]*core.Lambda[I, O]),
 		constructors: make(map[string]func() *core.Lambda[I, O]),
 		meta:         make(map[string]core.LambdaMeta),
+		defaults:     make(map[string]I),
 	}
 
 	globalRegistries.Store(key, reg)
@@ -66,10 +75,25 @@ func (r *Registry[I, O]) Register(lambda *core.Lambda[I, O]) error {
 	}
 
 	r.lambdas[name] = lambda
-	r.meta[name] = lambda.GetMeta()
+	r.meta[name] = registryMeta(lambda)
+	emit(Registered, r.meta[name])
 	return nil
 }
 
+// Replace 注册或替换lambda，返回被替换的旧lambda（不存在时为nil）
+func (r *Registry[I, O]) Replace(lambda *core.Lambda[I, O]) *core.Lambda[I, O] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := lambda.GetName()
+	previous := r.lambdas[name]
+
+	r.lambdas[name] = lambda
+	r.meta[name] = registryMeta(lambda)
+	emit(Registered, r.meta[name])
+	return previous
+}
+
 // RegisterWithConstructor 注册lambda构造函数
 func (r *Registry[I, O]) RegisterWithConstructor(name string, constructor func() *core.Lambda[I, O]) {
 	r.mu.Lock()
@@ -80,6 +104,8 @@ func (r *Registry[I, O]) RegisterWithConstructor(name string, constructor func()
 
 // Get 获取lambda
 func (r *Registry[I, O]) Get(name string) (*core.Lambda[I, O], bool) {
+	sweepRemovedVersions()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -129,6 +155,8 @@ func (r *Registry[I, O]) List() []string {
 
 // GetMeta 获取lambda元数据
 func (r *Registry[I, O]) GetMeta(name string) (core.LambdaMeta, bool) {
+	sweepRemovedVersions()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -138,6 +166,8 @@ func (r *Registry[I, O]) GetMeta(name string) (core.LambdaMeta, bool) {
 
 // GetAllMeta 获取所有lambda元数据
 func (r *Registry[I, O]) GetAllMeta() map[string]core.LambdaMeta {
+	sweepRemovedVersions()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -149,14 +179,57 @@ func (r *Registry[I, O]) GetAllMeta() map[string]core.LambdaMeta {
 	return metaCopy
 }
 
+// listMeta 返回注册表中所有lambda元数据的副本
+func (r *Registry[I, O]) listMeta() []core.LambdaMeta {
+	sweepRemovedVersions()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metas := make([]core.LambdaMeta, 0, len(r.meta))
+	for _, meta := range r.meta {
+		metas = append(metas, meta)
+	}
+
+	return metas
+}
+
+// FindByTag 查找带有指定标签的lambda名称
+func (r *Registry[I, O]) FindByTag(tag string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for name, meta := range r.meta {
+		if hasTag(meta, tag) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// hasTag 判断元数据是否包含指定标签
+func hasTag(meta core.LambdaMeta, tag string) bool {
+	for _, t := range meta.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // Unregister 注销lambda
 func (r *Registry[I, O]) Unregister(name string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.lambdas[name]; exists {
+		emit(Unregistered, r.meta[name])
 		delete(r.lambdas, name)
 		delete(r.meta, name)
+		delete(r.defaults, name)
 		return true
 	}
 
@@ -173,13 +246,20 @@ func (r *Registry[I, O]) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	for _, meta := range r.meta {
+		emit(Unregistered, meta)
+	}
+
 	r.lambdas = make(map[string]*core.Lambda[I, O])
 	r.constructors = make(map[string]func() *core.Lambda[I, O])
 	r.meta = make(map[string]core.LambdaMeta)
+	r.defaults = make(map[string]I)
 }
 
 // Count 返回注册的lambda数量
 func (r *Registry[I, O]) Count() int {
+	sweepRemovedVersions()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -195,6 +275,13 @@ func RegisterLambda[I any, O any](name string, invoke core.InvokeFunc[I, O], opt
 	return reg.Register(lambda)
 }
 
+// RegisterOrReplace 注册lambda到全局注册表，已存在时替换并返回旧lambda
+func RegisterOrReplace[I any, O any](name string, invoke core.InvokeFunc[I, O], opts ...core.LambdaOption) *core.Lambda[I, O] {
+	lambda := core.NewLambda(name, invoke, opts...)
+	reg := getRegistry[I, O]()
+	return reg.Replace(lambda)
+}
+
 // RegisterLambdaWithConstructor 注册lambda构造函数到全局注册表
 func RegisterLambdaWithConstructor[I any, O any](name string, constructor func() *core.Lambda[I, O]) {
 	reg := getRegistry[I, O]()
@@ -230,3 +317,44 @@ func UnregisterLambda[I any, O any](name string) bool {
 	reg := getRegistry[I, O]()
 	return reg.Unregister(name)
 }
+
+// ListAll 列出所有泛型类型组合下已注册lambda的元数据
+func ListAll() []core.LambdaMeta {
+	var metas []core.LambdaMeta
+
+	globalRegistries.Range(func(_, value any) bool {
+		if lister, ok := value.(metaLister); ok {
+			metas = append(metas, lister.listMeta()...)
+		}
+		return true
+	})
+
+	sort.Slice(metas, func(i, j int) bool {
+		if metas[i].Namespace != metas[j].Namespace {
+			return metas[i].Namespace < metas[j].Namespace
+		}
+		if metas[i].Name != metas[j].Name {
+			return metas[i].Name < metas[j].Name
+		}
+		return metas[i].InputType+metas[i].OutputType < metas[j].InputType+metas[j].OutputType
+	})
+
+	return metas
+}
+
+// FindByTag 从全局注册表查找带有指定标签的lambda名称
+func FindByTag[I any, O any](tag string) []string {
+	reg := getRegistry[I, O]()
+	return reg.FindByTag(tag)
+}
+
+// FindByTagAll 在所有泛型类型组合中查找带有指定标签的lambda元数据
+func FindByTagAll(tag string) []core.LambdaMeta {
+	var metas []core.LambdaMeta
+	for _, meta := range ListAll() {
+		if hasTag(meta, tag) {
+			metas = append(metas, meta)
+		}
+	}
+	return metas
+}
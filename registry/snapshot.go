@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// RegistrySnapshot 注册中心的只读清单，只包含元数据，不包含lambda函数本身
+type RegistrySnapshot struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Lambdas     []SnapshotEntry `json:"lambdas"`
+}
+
+// SnapshotEntry 清单中的一个lambda
+type SnapshotEntry struct {
+	Name          string    `json:"name"`
+	Namespace     string    `json:"namespace,omitempty"`
+	InputType     string    `json:"input_type"`
+	OutputType    string    `json:"output_type"`
+	ComponentType string    `json:"component_type,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	RegisteredAt  time.Time `json:"registered_at"`
+}
+
+// key 以完整名称和类型签名标识lambda
+func (e SnapshotEntry) key() string {
+	return QualifiedName(e.Namespace, e.Name) + " (" + e.InputType + "->" + e.OutputType + ")"
+}
+
+// MetaDiff 清单与当前注册中心的差异，均按标识排序
+// 标识格式为 "namespace/name (input->output)"，没有命名空间时省略前缀
+type MetaDiff struct {
+	Missing []string // 清单中有、注册中心中没有
+	Extra   []string // 注册中心中有、清单中没有
+}
+
+// snapshotEntries 返回当前注册中心的清单条目
+func snapshotEntries() []SnapshotEntry {
+	metas := ListAll()
+	entries := make([]SnapshotEntry, 0, len(metas))
+	for _, meta := range metas {
+		entries = append(entries, snapshotEntryOf(meta))
+	}
+	return entries
+}
+
+// snapshotEntryOf 把元数据转换为清单条目
+func snapshotEntryOf(meta core.LambdaMeta) SnapshotEntry {
+	return SnapshotEntry{
+		Name:          meta.Name,
+		Namespace:     meta.Namespace,
+		InputType:     meta.InputType,
+		OutputType:    meta.OutputType,
+		ComponentType: meta.ComponentType,
+		Tags:          meta.Tags,
+		RegisteredAt:  meta.RegisteredAt,
+	}
+}
+
+// ExportMeta 把所有类型组合下已注册lambda的元数据导出为 JSON
+func ExportMeta() ([]byte, error) {
+	snapshot := RegistrySnapshot{
+		GeneratedAt: time.Now(),
+		Lambdas:     snapshotEntries(),
+	}
+	return json.Marshal(snapshot)
+}
+
+// ImportMeta 解析 ExportMeta 导出的清单并与当前注册中心比较
+// 只用于校验和比对，不会修改注册中心
+func ImportMeta(data []byte) (MetaDiff, error) {
+	var snapshot RegistrySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return MetaDiff{}, fmt.Errorf("invalid registry snapshot: %w", err)
+	}
+
+	expected := make(map[string]bool, len(snapshot.Lambdas))
+	for _, entry := range snapshot.Lambdas {
+		expected[entry.key()] = true
+	}
+
+	live := make(map[string]bool)
+	for _, entry := range snapshotEntries() {
+		live[entry.key()] = true
+	}
+
+	var diff MetaDiff
+	for key := range expected {
+		if !live[key] {
+			diff.Missing = append(diff.Missing, key)
+		}
+	}
+	for key := range live {
+		if !expected[key] {
+			diff.Extra = append(diff.Extra, key)
+		}
+	}
+
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	return diff, nil
+}
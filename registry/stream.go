@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// StreamRegistry 双向流lambda注册中心
+type StreamRegistry[I any, O any] struct {
+	mu      sync.RWMutex
+	streams map[string]*core.StreamLambda[I, O]
+}
+
+// globalStreamRegistries 存储所有泛型类型组合的流注册表
+var globalStreamRegistries = sync.Map{}
+
+// getStreamRegistry 获取或创建指定泛型类型的流注册表
+func getStreamRegistry[I any, O any]() *StreamRegistry[I, O] {
+	reg, _ := globalStreamRegistries.LoadOrStore(registryKey[I, O](), &StreamRegistry[I, O]{
+		streams: make(map[string]*core.StreamLambda[I, O]),
+	})
+	return reg.(*StreamRegistry[I, O])
+}
+
+// Register 注册双向流lambda
+func (r *StreamRegistry[I, O]) Register(lambda *core.StreamLambda[I, O]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := lambda.GetName()
+	if _, exists := r.streams[name]; exists {
+		return fmt.Errorf("stream lambda '%s' already registered", name)
+	}
+
+	r.streams[name] = lambda
+	return nil
+}
+
+// Get 获取双向流lambda
+func (r *StreamRegistry[I, O]) Get(name string) (*core.StreamLambda[I, O], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lambda, exists := r.streams[name]
+	return lambda, exists
+}
+
+// Unregister 注销双向流lambda
+func (r *StreamRegistry[I, O]) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.streams[name]; !exists {
+		return false
+	}
+	delete(r.streams, name)
+	return true
+}
+
+// RegisterStreamLambda 注册双向流lambda到全局注册表
+func RegisterStreamLambda[I any, O any](name string, stream core.StreamFunc[I, O]) error {
+	reg := getStreamRegistry[I, O]()
+	return reg.Register(core.NewStreamLambda(name, stream))
+}
+
+// GetStreamLambda 从全局注册表获取双向流lambda
+func GetStreamLambda[I any, O any](name string) (*core.StreamLambda[I, O], bool) {
+	reg := getStreamRegistry[I, O]()
+	return reg.Get(name)
+}
+
+// UnregisterStreamLambda 从全局注册表注销双向流lambda
+func UnregisterStreamLambda[I any, O any](name string) bool {
+	reg := getStreamRegistry[I, O]()
+	return reg.Unregister(name)
+}
@@ -0,0 +1,232 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// ErrVersionRemoved 请求的版本已过移除期限
+var ErrVersionRemoved = errors.New("lambda version removed")
+
+// VersionState 版本生命周期状态
+type VersionState int
+
+const (
+	// VersionActive 当前版本或仍在宽限期内的旧版本
+	VersionActive VersionState = iota
+	// VersionDeprecated 已弃用，调用仍可执行但会记录警告
+	VersionDeprecated
+	// VersionRemoved 已移除，调用被拒绝
+	VersionRemoved
+)
+
+// String 返回状态名称
+func (s VersionState) String() string {
+	switch s {
+	case VersionActive:
+		return "active"
+	case VersionDeprecated:
+		return "deprecated"
+	case VersionRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// versionEntry 单个版本的生命周期信息
+type versionEntry struct {
+	version        string
+	deprecateAfter time.Duration
+	removeAfter    time.Duration
+	supersededAt   time.Time // 被新版本取代的时间，零值表示仍是最新版本
+	unregister     func() bool
+	removed        bool // 已从注册中心注销
+}
+
+// versionRegistry 版本生命周期管理
+type versionRegistry struct {
+	mu       sync.Mutex
+	versions map[string][]*versionEntry
+	now      func() time.Time
+	// pending 已被取代、将来需要注销的版本数，为 0 时 sweep 直接返回
+	pending atomic.Int64
+}
+
+var globalVersions = &versionRegistry{
+	versions: make(map[string][]*versionEntry),
+	now:      time.Now,
+}
+
+// SetVersionClock 设置版本生命周期使用的时钟，传入 nil 恢复为 time.Now
+func SetVersionClock(now func() time.Time) {
+	globalVersions.mu.Lock()
+	defer globalVersions.mu.Unlock()
+
+	if now == nil {
+		now = time.Now
+	}
+	globalVersions.now = now
+}
+
+// versionedName 返回版本在注册中心中的名称
+func versionedName(name, version string) string {
+	return name + "@" + version
+}
+
+// RegisterLambdaVersion 注册lambda的一个版本
+// 版本以 "name@version" 的名称注册到注册中心；最后注册的版本为当前版本。
+// 注册新版本时，之前的版本进入宽限期：被取代 deprecateAfter 后标记为弃用（调用时记录警告），
+// removeAfter 后移除：InvokeVersion 返回 ErrVersionRemoved，"name@version" 也从注册中心注销。
+// duration <= 0 表示不进入对应阶段。
+func RegisterLambdaVersion[I any, O any](name, version string, invoke core.InvokeFunc[I, O], deprecateAfter, removeAfter time.Duration, opts ...core.LambdaOption) error {
+	if err := RegisterLambda(versionedName(name, version), invoke, opts...); err != nil {
+		return err
+	}
+
+	globalVersions.mu.Lock()
+	defer globalVersions.mu.Unlock()
+
+	now := globalVersions.now()
+	for _, entry := range globalVersions.versions[name] {
+		if entry.supersededAt.IsZero() {
+			entry.supersededAt = now
+			if entry.removeAfter > 0 {
+				globalVersions.pending.Add(1)
+			}
+		}
+	}
+	qualified := versionedName(name, version)
+	globalVersions.versions[name] = append(globalVersions.versions[name], &versionEntry{
+		version:        version,
+		deprecateAfter: deprecateAfter,
+		removeAfter:    removeAfter,
+		unregister:     func() bool { return getRegistry[I, O]().Unregister(qualified) },
+	})
+	return nil
+}
+
+// sweep 注销已过移除期限的版本
+// 注册中心的查找与列举入口在加锁前调用，使 "name@version" 在移除后不能再通过
+// GetLambda、invoker 或 InvokeJSON 调用，也不再出现在 ListAll 中。
+func (vr *versionRegistry) sweep() {
+	if vr.pending.Load() == 0 {
+		return
+	}
+
+	var expired []*versionEntry
+	vr.mu.Lock()
+	for _, entries := range vr.versions {
+		for _, entry := range entries {
+			if !entry.removed && vr.state(entry) == VersionRemoved {
+				entry.removed = true
+				vr.pending.Add(-1)
+				expired = append(expired, entry)
+			}
+		}
+	}
+	vr.mu.Unlock()
+
+	// 在版本锁之外注销，避免与注册表的锁形成嵌套
+	for _, entry := range expired {
+		entry.unregister()
+	}
+}
+
+// sweepRemovedVersions 注销已过移除期限的版本
+func sweepRemovedVersions() {
+	globalVersions.sweep()
+}
+
+// state 计算版本当前状态，需在持有锁时调用
+func (vr *versionRegistry) state(entry *versionEntry) VersionState {
+	if entry.supersededAt.IsZero() {
+		return VersionActive
+	}
+
+	elapsed := vr.now().Sub(entry.supersededAt)
+	if entry.removeAfter > 0 && elapsed >= entry.removeAfter {
+		return VersionRemoved
+	}
+	if entry.deprecateAfter > 0 && elapsed >= entry.deprecateAfter {
+		return VersionDeprecated
+	}
+	return VersionActive
+}
+
+// lookup 查找版本信息
+func (vr *versionRegistry) lookup(name, version string) (VersionState, bool) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+
+	for _, entry := range vr.versions[name] {
+		if entry.version == version {
+			return vr.state(entry), true
+		}
+	}
+	return VersionActive, false
+}
+
+// GetVersionState 返回版本当前的生命周期状态
+func GetVersionState(name, version string) (VersionState, bool) {
+	return globalVersions.lookup(name, version)
+}
+
+// LatestVersion 返回lambda最后注册的版本
+func LatestVersion(name string) (string, bool) {
+	globalVersions.mu.Lock()
+	defer globalVersions.mu.Unlock()
+
+	versions := globalVersions.versions[name]
+	if len(versions) == 0 {
+		return "", false
+	}
+	return versions[len(versions)-1].version, true
+}
+
+// GetLambdaVersion 获取lambda的指定版本
+// 已弃用的版本会记录警告日志，已移除的版本返回 ErrVersionRemoved
+func GetLambdaVersion[I any, O any](name, version string) (*core.Lambda[I, O], error) {
+	state, exists := globalVersions.lookup(name, version)
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s' version '%s'", core.ErrLambdaNotFound, name, version)
+	}
+
+	switch state {
+	case VersionRemoved:
+		sweepRemovedVersions()
+		return nil, fmt.Errorf("%w: '%s' version '%s'", ErrVersionRemoved, name, version)
+	case VersionDeprecated:
+		log.Printf("[Registry] WARNING: lambda '%s' version '%s' is deprecated", name, version)
+	}
+
+	lambda, exists := GetLambda[I, O](versionedName(name, version))
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s' version '%s'", core.ErrLambdaNotFound, name, version)
+	}
+	return lambda, nil
+}
+
+// InvokeVersion 调用lambda的指定版本，version 为空时调用最新版本
+func InvokeVersion[I any, O any](ctx context.Context, name, version string, input I) (*core.LambdaResult[O], error) {
+	if version == "" {
+		latest, exists := LatestVersion(name)
+		if !exists {
+			return nil, fmt.Errorf("lambda '%s' has no versions", name)
+		}
+		version = latest
+	}
+
+	lambda, err := GetLambdaVersion[I, O](name, version)
+	if err != nil {
+		return nil, err
+	}
+	return lambda.Invoke(ctx, input)
+}
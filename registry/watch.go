@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"sync"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// RegistryEventType 注册事件类型
+type RegistryEventType int
+
+const (
+	// Registered lambda被注册或替换
+	Registered RegistryEventType = iota
+	// Unregistered lambda被注销
+	Unregistered
+)
+
+// String 返回事件类型名称
+func (t RegistryEventType) String() string {
+	switch t {
+	case Registered:
+		return "registered"
+	case Unregistered:
+		return "unregistered"
+	default:
+		return "unknown"
+	}
+}
+
+// RegistryEvent 注册事件
+type RegistryEvent struct {
+	Type RegistryEventType
+	Meta core.LambdaMeta
+}
+
+// watchBufferSize 每个订阅者的事件缓冲大小
+const watchBufferSize = 64
+
+// watchers 注册事件的订阅者
+var watchers = struct {
+	mu   sync.Mutex
+	subs map[chan RegistryEvent]struct{}
+}{subs: make(map[chan RegistryEvent]struct{})}
+
+// Watch 订阅注册事件
+// Register/Replace 发出 Registered，Unregister/Clear 发出 Unregistered。
+// 每个订阅者有独立的缓冲区，缓冲区满时丢弃最旧的事件，注册方不会被阻塞。
+func Watch() <-chan RegistryEvent {
+	ch := make(chan RegistryEvent, watchBufferSize)
+
+	watchers.mu.Lock()
+	watchers.subs[ch] = struct{}{}
+	watchers.mu.Unlock()
+
+	return ch
+}
+
+// Unwatch 取消订阅并关闭通道
+func Unwatch(events <-chan RegistryEvent) {
+	watchers.mu.Lock()
+	defer watchers.mu.Unlock()
+
+	for ch := range watchers.subs {
+		if ch == events {
+			delete(watchers.subs, ch)
+			close(ch)
+			return
+		}
+	}
+}
+
+// emit 向所有订阅者发送事件，不阻塞
+func emit(eventType RegistryEventType, meta core.LambdaMeta) {
+	watchers.mu.Lock()
+	defer watchers.mu.Unlock()
+
+	event := RegistryEvent{Type: eventType, Meta: meta}
+	for ch := range watchers.subs {
+		select {
+		case ch <- event:
+			continue
+		default:
+		}
+
+		// 缓冲区已满，丢弃最旧的事件
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
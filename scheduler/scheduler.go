@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/invoker"
+)
+
+// Job 定时调用任务
+type Job[O any] struct {
+	name       string
+	mu         sync.RWMutex
+	runs       int64
+	lastResult *core.LambdaResult[O]
+	lastErr    error
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// newTicker 创建调度使用的节拍，返回节拍通道与停止函数，可通过 SetTicker 替换
+var (
+	tickerMu  sync.Mutex
+	newTicker = realTicker
+)
+
+// realTicker 基于 time.Ticker 的节拍
+func realTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// SetTicker 设置之后创建的任务使用的节拍，传入 nil 恢复为 time.Ticker
+// 主要用于测试：由调用方控制何时触发执行，而不依赖真实时间。
+func SetTicker(ticker func(interval time.Duration) (<-chan time.Time, func())) {
+	tickerMu.Lock()
+	defer tickerMu.Unlock()
+
+	if ticker == nil {
+		ticker = realTicker
+	}
+	newTicker = ticker
+}
+
+// Schedule 每隔 interval 以 input 调用一次指定lambda，直到调用 Stop
+// interval 必须为正数，否则在调用方 panic，而不是在后台goroutine中崩溃整个进程。
+func Schedule[I any, O any](name string, input I, interval time.Duration) *Job[O] {
+	if interval <= 0 {
+		panic(fmt.Sprintf("scheduler: interval for '%s' must be positive, got %v", name, interval))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job[O]{
+		name:   name,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	inv := invoker.NewInvoker[I, O]()
+
+	tickerMu.Lock()
+	ticks, stop := newTicker(interval)
+	tickerMu.Unlock()
+
+	go func() {
+		defer close(job.done)
+		defer stop()
+
+		for {
+			select {
+			case <-ticks:
+				result, err := inv.Invoke(ctx, name, input)
+				job.record(result, err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return job
+}
+
+// record 记录一次执行结果
+func (j *Job[O]) record(result *core.LambdaResult[O], err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.runs++
+	j.lastResult = result
+	j.lastErr = err
+}
+
+// Stop 停止任务并等待后台goroutine退出，可重复调用
+func (j *Job[O]) Stop() {
+	j.cancel()
+	<-j.done
+}
+
+// Name 返回调度的lambda名称
+func (j *Job[O]) Name() string {
+	return j.name
+}
+
+// Runs 返回已执行的次数
+func (j *Job[O]) Runs() int64 {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.runs
+}
+
+// LastResult 返回最近一次执行的结果和错误
+func (j *Job[O]) LastResult() (*core.LambdaResult[O], error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.lastResult, j.lastErr
+}
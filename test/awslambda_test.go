@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/awslambda"
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+func TestAWSLambdaHandlerDispatchesEvent(t *testing.T) {
+	var started any
+	awslambda.Start[Person, PersonGreeting]("validate_person", func(handler any) { started = handler })
+
+	handler, ok := started.(awslambda.Handler[PersonGreeting])
+	if !ok {
+		t.Fatalf("Expected Start to pass an awslambda.Handler, got %T", started)
+	}
+
+	greeting, err := handler(context.Background(), json.RawMessage(`{"Name":"Alice","Age":30}`))
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+	if greeting.Message == "" {
+		t.Errorf("Expected greeting message, got %+v", greeting)
+	}
+
+	if _, err := handler(context.Background(), json.RawMessage(`"not an object"`)); !errors.Is(err, registry.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload, got %v", err)
+	}
+
+	missing := awslambda.NewHandler[int, int]("aws_missing")
+	if _, err := missing(context.Background(), json.RawMessage(`1`)); !errors.Is(err, core.ErrLambdaNotFound) {
+		t.Errorf("Expected ErrLambdaNotFound, got %v", err)
+	}
+}
+
+func TestAWSLambdaHandlerPropagatesDeadline(t *testing.T) {
+	registry.RegisterOrReplace("aws_slow", func(ctx context.Context, input int) (int, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			return input, nil
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := awslambda.NewHandler[int, int]("aws_slow")(ctx, json.RawMessage(`1`))
+	if !errors.Is(err, core.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline to propagate as timeout, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/contextx"
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// userIDKey 演示用的 context 键类型
+type userIDKey struct{}
+
+// requireUser 与示例中 Auth 中间件相同的鉴权逻辑
+func requireUser[I any, O any](key any) core.Middleware[I, O] {
+	return func(ctx context.Context, input I, next core.InvokeFunc[I, O]) (O, error) {
+		if userID := ctx.Value(key); userID == nil {
+			var zero O
+			return zero, fmt.Errorf("unauthorized: no user_id in context")
+		}
+		return next(ctx, input)
+	}
+}
+
+func TestWithContextValueBeforeAuth(t *testing.T) {
+	handler := func(ctx context.Context, input string) (string, error) {
+		return fmt.Sprintf("%v:%s", ctx.Value(userIDKey{}), input), nil
+	}
+
+	unauthenticated := core.NewLambdaWithMiddleware("auth", handler, requireUser[string, string](userIDKey{}))
+	if _, err := unauthenticated.Invoke(context.Background(), "data"); err == nil {
+		t.Fatal("Expected unauthenticated call to fail")
+	}
+
+	authenticated := core.NewLambdaWithMiddleware("auth", handler,
+		core.WithContextValue[string, string](userIDKey{}, 42),
+		requireUser[string, string](userIDKey{}),
+	)
+
+	result, err := authenticated.Invoke(context.Background(), "data")
+	if err != nil {
+		t.Fatalf("Expected authenticated call to succeed, got %v", err)
+	}
+	if result.Output != "42:data" {
+		t.Errorf("Expected '42:data', got '%s'", result.Output)
+	}
+}
+
+func TestWithTypedContextValue(t *testing.T) {
+	userKey := core.NewContextKey[int]("user_id")
+	otherKey := core.NewContextKey[int]("user_id")
+
+	handler := func(ctx context.Context, input string) (int, error) {
+		if _, ok := otherKey.Value(ctx); ok {
+			return 0, fmt.Errorf("keys with the same name must not collide")
+		}
+		userID, ok := userKey.Value(ctx)
+		if !ok {
+			return 0, fmt.Errorf("missing user_id")
+		}
+		return userID, nil
+	}
+
+	lambda := core.NewLambdaWithMiddleware("typed_ctx", handler,
+		core.WithTypedContextValue[string, int](userKey, 7),
+	)
+
+	result, err := lambda.Invoke(context.Background(), "x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Output != 7 {
+		t.Errorf("Expected 7, got %d", result.Output)
+	}
+}
+
+func TestStageTimeoutLeavesHandlerContextIntact(t *testing.T) {
+	var cacheErr error
+	slowCache := func(ctx context.Context, input string, next core.InvokeFunc[string, string]) (string, error) {
+		// 缓存查询只受阶段 context 约束
+		select {
+		case <-time.After(time.Second):
+			return "cached", nil
+		case <-core.StageContext(ctx).Done():
+			cacheErr = core.StageContext(ctx).Err()
+		}
+		return next(core.EndStage(ctx), input)
+	}
+
+	parent, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+
+	var handlerErr error
+	var handlerDeadline time.Time
+	var stageVisible bool
+	lambda := core.NewLambdaWithMiddleware("stage_timeout",
+		func(ctx context.Context, input string) (string, error) {
+			handlerErr = ctx.Err()
+			handlerDeadline, _ = ctx.Deadline()
+			stageVisible = core.StageContext(ctx) != ctx
+			return "fresh", nil
+		},
+		core.StageTimeout[string, string](10*time.Millisecond),
+		slowCache,
+	)
+
+	result, err := lambda.Invoke(parent, "key")
+	if err != nil {
+		t.Fatalf("Invocation failed: %v", err)
+	}
+	if result.Output != "fresh" {
+		t.Errorf("Expected handler output after cache timeout, got %q", result.Output)
+	}
+	if !errors.Is(cacheErr, context.DeadlineExceeded) {
+		t.Errorf("Expected cache stage to time out, got %v", cacheErr)
+	}
+	if handlerErr != nil {
+		t.Errorf("Expected handler context to be live, got %v", handlerErr)
+	}
+	if !handlerDeadline.Equal(parentDeadline) {
+		t.Errorf("Expected handler deadline %v, got %v", parentDeadline, handlerDeadline)
+	}
+	if stageVisible {
+		t.Error("Expected stage context to be cleared for the handler")
+	}
+}
+
+func TestContextxUser(t *testing.T) {
+	ctx := contextx.WithUser(context.Background(), contextx.User{ID: 42, Name: "alice", Roles: []string{"admin"}})
+
+	user, ok := contextx.UserFromContext(ctx)
+	if !ok {
+		t.Fatal("Expected user in context")
+	}
+	if user.ID != 42 || user.Name != "alice" || !user.HasRole("admin") || user.HasRole("guest") {
+		t.Errorf("Unexpected user %+v", user)
+	}
+}
+
+func TestContextxUserMissing(t *testing.T) {
+	user, ok := contextx.UserFromContext(context.Background())
+	if ok {
+		t.Errorf("Expected ok=false without a user, got %+v", user)
+	}
+	if user.ID != 0 || user.Roles != nil {
+		t.Errorf("Expected zero user, got %+v", user)
+	}
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/events"
+)
+
+func TestBindLambdaToTopic(t *testing.T) {
+	bus := events.NewBus()
+
+	var received []any
+	bus.Subscribe("numbers.doubled", func(ctx context.Context, payload any) error {
+		received = append(received, payload)
+		return nil
+	})
+
+	unbind := events.BindLambda[int, int](bus, "numbers", "math_double", "numbers.doubled")
+
+	if err := bus.Publish("numbers", 21); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(received) != 1 || received[0] != 42 {
+		t.Fatalf("Expected [42] downstream, got %v", received)
+	}
+
+	// 类型不匹配返回错误而不是panic
+	if err := bus.Publish("numbers", "not a number"); err == nil {
+		t.Error("Expected type mismatch error")
+	}
+
+	unbind()
+	bus.Publish("numbers", 1)
+	if len(received) != 1 {
+		t.Errorf("Expected no downstream events after unbind, got %v", received)
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/grpcserver"
+)
+
+func TestDispatchServerInvoke(t *testing.T) {
+	server := grpcserver.NewDispatchServer()
+
+	resp, err := server.Invoke(context.Background(), &grpcserver.InvokeRequest{
+		Name:    "string_upper",
+		Payload: []byte(`"hello grpc"`),
+	})
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+	if string(resp.Payload) != `"HELLO GRPC"` {
+		t.Errorf("Expected \"HELLO GRPC\", got %s", resp.Payload)
+	}
+}
+
+func TestDispatchServerStatusCodes(t *testing.T) {
+	server := grpcserver.NewDispatchServer()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  *grpcserver.InvokeRequest
+		code grpcserver.Code
+	}{
+		{"not found", context.Background(), &grpcserver.InvokeRequest{Name: "no_such_lambda", Payload: []byte(`1`)}, grpcserver.NotFound},
+		{"invalid payload", context.Background(), &grpcserver.InvokeRequest{Name: "string_upper", Payload: []byte(`42`)}, grpcserver.InvalidArgument},
+		{"handler error", context.Background(), &grpcserver.InvokeRequest{Name: "math_factorial", Payload: []byte(`-1`)}, grpcserver.Unknown},
+		{"deadline", expired, &grpcserver.InvokeRequest{Name: "string_upper", Payload: []byte(`"x"`)}, grpcserver.DeadlineExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := server.Invoke(test.ctx, test.req)
+			if code := grpcserver.CodeOf(err); code != test.code {
+				t.Errorf("Expected code %s, got %s (%v)", test.code, code, err)
+			}
+		})
+	}
+}
+
+func TestDispatchServerGzipPayload(t *testing.T) {
+	server := grpcserver.NewDispatchServer()
+
+	payload, err := core.GzipCompress([]byte(`"hello"`))
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	resp, err := server.Invoke(context.Background(), &grpcserver.InvokeRequest{
+		Name:    "string_upper",
+		Payload: payload,
+		Gzip:    true,
+	})
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+	if !resp.Gzip {
+		t.Error("Expected gzip response")
+	}
+
+	output, err := core.GzipDecompress(resp.Payload)
+	if err != nil {
+		t.Fatalf("Decompress failed: %v", err)
+	}
+	if string(output) != `"HELLO"` {
+		t.Errorf("Expected \"HELLO\", got %s", output)
+	}
+
+	_, err = server.Invoke(context.Background(), &grpcserver.InvokeRequest{
+		Name:    "string_upper",
+		Payload: []byte(`"hello"`),
+		Gzip:    true,
+	})
+	if grpcserver.CodeOf(err) != grpcserver.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for non-gzip payload, got %v", err)
+	}
+}
@@ -0,0 +1,836 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/invoker"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+func TestInvokeCallback(t *testing.T) {
+	inv := invoker.NewInvoker[string, string]()
+
+	done := make(chan *core.LambdaResult[string], 1)
+	inv.InvokeCallback(context.Background(), "string_upper", "callback", func(result *core.LambdaResult[string]) {
+		done <- result
+	})
+
+	select {
+	case result := <-done:
+		if result.Error != nil {
+			t.Fatalf("Callback invocation failed: %v", result.Error)
+		}
+		if result.Output != "CALLBACK" {
+			t.Errorf("Expected 'CALLBACK', got '%s'", result.Output)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Callback was not called")
+	}
+}
+
+func TestInvokeDuplex(t *testing.T) {
+	defer registry.UnregisterStreamLambda[int, int]("stream_double")
+
+	err := registry.RegisterStreamLambda("stream_double", func(ctx context.Context, input <-chan int, output chan<- int) error {
+		for n := range input {
+			select {
+			case output <- n * 2:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to register stream lambda: %v", err)
+	}
+
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := 1; i <= 5; i++ {
+			inputs <- i
+		}
+	}()
+
+	inv := invoker.NewInvoker[int, int]()
+	outputs, errChan := inv.InvokeDuplex(context.Background(), "stream_double", inputs)
+
+	var got []int
+	for output := range outputs {
+		got = append(got, output)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Stream lambda failed: %v", err)
+	}
+
+	expected := []int{2, 4, 6, 8, 10}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Output %d: expected %d, got %d", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestInvokeEachPreservesOrder(t *testing.T) {
+	registry.RegisterOrReplace("each_slow_square", func(ctx context.Context, input int) (int, error) {
+		// 输入越小耗时越长，打乱完成顺序
+		time.Sleep(time.Duration(10-input) * time.Millisecond)
+		if input == 5 {
+			return 0, errors.New("five is not allowed")
+		}
+		return input * input, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]()
+	inputs := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	results, err := inv.InvokeEach(context.Background(), "each_slow_square", inputs, 4)
+	if err != nil {
+		t.Fatalf("InvokeEach failed: %v", err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("Expected %d results, got %d", len(inputs), len(results))
+	}
+
+	for i, input := range inputs {
+		if input == 5 {
+			if results[i].Error == nil {
+				t.Error("Expected handler error for input 5")
+			}
+			continue
+		}
+		if results[i].Output != input*input {
+			t.Errorf("Result %d: expected %d, got %d", i, input*input, results[i].Output)
+		}
+	}
+
+	if _, err := inv.InvokeEach(context.Background(), "each_missing", inputs, 4); err == nil {
+		t.Error("Expected lookup error for missing lambda")
+	}
+}
+
+func TestInvokeEachConcurrencyBound(t *testing.T) {
+	var inFlight, maxInFlight int32
+	registry.RegisterOrReplace("each_tracked", func(ctx context.Context, input int) (int, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return input, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]()
+	inputs := make([]int, 20)
+	if _, err := inv.InvokeEach(context.Background(), "each_tracked", inputs, 3); err != nil {
+		t.Fatalf("InvokeEach failed: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 3 {
+		t.Errorf("Expected at most 3 concurrent invocations, got %d", got)
+	}
+}
+
+func TestInvokeFirstSuccess(t *testing.T) {
+	var slowCancelled atomic.Bool
+
+	registry.RegisterOrReplace("replica_fast_fail", func(ctx context.Context, input string) (string, error) {
+		return "", errors.New("fast replica down")
+	})
+	registry.RegisterOrReplace("replica_medium", func(ctx context.Context, input string) (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "medium:" + input, nil
+	})
+	registry.RegisterOrReplace("replica_slow", func(ctx context.Context, input string) (string, error) {
+		select {
+		case <-time.After(time.Second):
+			return "slow:" + input, nil
+		case <-ctx.Done():
+			slowCancelled.Store(true)
+			return "", ctx.Err()
+		}
+	})
+
+	inv := invoker.NewInvoker[string, string]()
+	result, err := inv.InvokeFirstSuccess(context.Background(),
+		[]string{"replica_fast_fail", "replica_medium", "replica_slow"}, "req")
+	if err != nil {
+		t.Fatalf("Expected a successful result, got %v", err)
+	}
+	if result.Output != "medium:req" {
+		t.Errorf("Expected 'medium:req', got '%s'", result.Output)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !slowCancelled.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !slowCancelled.Load() {
+		t.Error("Expected slower replica to be cancelled")
+	}
+
+	_, err = inv.InvokeFirstSuccess(context.Background(), []string{"replica_fast_fail", "replica_missing"}, "req")
+	if err == nil {
+		t.Fatal("Expected combined error when all lambdas fail")
+	}
+	if !strings.Contains(err.Error(), "fast replica down") || !strings.Contains(err.Error(), "replica_missing") {
+		t.Errorf("Expected combined error to mention every failure, got %v", err)
+	}
+}
+
+func TestMapReduceSumOfSquares(t *testing.T) {
+	total, err := invoker.MapReduce(context.Background(), "math_square", []int{1, 2, 3, 4, 5},
+		func(acc int, output int) int { return acc + output }, 0)
+	if err != nil {
+		t.Fatalf("MapReduce failed: %v", err)
+	}
+	if total != 55 {
+		t.Errorf("Expected 55, got %d", total)
+	}
+
+	_, err = invoker.MapReduce(context.Background(), "math_factorial", []int{3, -1, 4},
+		func(acc int, output int) int { return acc + output }, 0)
+	if err == nil {
+		t.Error("Expected MapReduce to fail when a handler errors")
+	}
+}
+
+func TestMapReduceBoundsConcurrency(t *testing.T) {
+	var active, peak int32
+	registry.RegisterOrReplace("mapreduce_tracked", func(ctx context.Context, input int) (int, error) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		return input, nil
+	})
+	defer registry.UnregisterLambda[int, int]("mapreduce_tracked")
+
+	inputs := make([]int, 200)
+	for i := range inputs {
+		inputs[i] = 1
+	}
+
+	total, err := invoker.MapReduce(context.Background(), "mapreduce_tracked", inputs,
+		func(acc int, output int) int { return acc + output }, 0)
+	if err != nil || total != len(inputs) {
+		t.Fatalf("Expected %d, got %d (err=%v)", len(inputs), total, err)
+	}
+	if limit := int32(runtime.GOMAXPROCS(0)); atomic.LoadInt32(&peak) > limit {
+		t.Errorf("Expected at most %d concurrent invocations, got %d", limit, peak)
+	}
+}
+
+func TestInvokeEscalating(t *testing.T) {
+	var fastCalls, reliableCalls int32
+
+	registry.RegisterOrReplace("escalate_fast", func(ctx context.Context, input int) (int, error) {
+		atomic.AddInt32(&fastCalls, 1)
+		return 0, errors.New("fast path unavailable")
+	})
+	registry.RegisterOrReplace("escalate_reliable", func(ctx context.Context, input int) (int, error) {
+		atomic.AddInt32(&reliableCalls, 1)
+		return input * 10, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]()
+	result, err := inv.InvokeEscalating(context.Background(), "escalate_fast", "escalate_reliable", 4, 2)
+	if err != nil {
+		t.Fatalf("Expected escalation to succeed, got %v", err)
+	}
+	if result.Output != 40 {
+		t.Errorf("Expected 40, got %d", result.Output)
+	}
+	if got := atomic.LoadInt32(&fastCalls); got != 3 {
+		t.Errorf("Expected 3 fast attempts, got %d", got)
+	}
+	if got := atomic.LoadInt32(&reliableCalls); got != 1 {
+		t.Errorf("Expected 1 reliable call, got %d", got)
+	}
+}
+
+func TestInvokeStream(t *testing.T) {
+	inv := invoker.NewInvoker[int, int]()
+
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := 0; i < 1000; i++ {
+			inputs <- i
+		}
+	}()
+
+	seen := make(map[int]bool)
+	for result := range inv.InvokeStream(context.Background(), "math_double", inputs, 8) {
+		if result.Error != nil {
+			t.Fatalf("Unexpected error: %v", result.Error)
+		}
+		seen[result.Output] = true
+	}
+
+	if len(seen) != 1000 {
+		t.Fatalf("Expected 1000 results, got %d", len(seen))
+	}
+	for i := 0; i < 1000; i++ {
+		if !seen[i*2] {
+			t.Errorf("Missing result for input %d", i)
+		}
+	}
+}
+
+func TestInvokeStreamOrdered(t *testing.T) {
+	inv := invoker.NewInvoker[int, int]()
+
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := 0; i < 1000; i++ {
+			inputs <- i
+		}
+	}()
+
+	count := 0
+	for result := range inv.InvokeStreamOrdered(context.Background(), "math_double", inputs, 8) {
+		if result.Output != count*2 {
+			t.Fatalf("Result %d: expected %d, got %d", count, count*2, result.Output)
+		}
+		count++
+	}
+
+	if count != 1000 {
+		t.Errorf("Expected 1000 results, got %d", count)
+	}
+}
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	var failing atomic.Bool
+	registry.RegisterOrReplace("adaptive_backend", func(ctx context.Context, input int) (int, error) {
+		if failing.Load() {
+			return 0, errors.New("backend overloaded")
+		}
+		return input, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]().WithAdaptiveConcurrency(1, 8)
+	if got := inv.AdaptiveLimit("adaptive_backend"); got != 8 {
+		t.Fatalf("Expected initial limit 8, got %d", got)
+	}
+
+	// 故障期间并发上限应快速下降
+	failing.Store(true)
+	for i := 0; i < 5; i++ {
+		inv.Invoke(context.Background(), "adaptive_backend", i)
+	}
+	if got := inv.AdaptiveLimit("adaptive_backend"); got != 1 {
+		t.Errorf("Expected limit to drop to 1 after failures, got %d", got)
+	}
+
+	// 恢复后逐步回升
+	failing.Store(false)
+	for i := 0; i < 3; i++ {
+		inv.Invoke(context.Background(), "adaptive_backend", i)
+	}
+	if got := inv.AdaptiveLimit("adaptive_backend"); got != 4 {
+		t.Errorf("Expected limit to recover to 4, got %d", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		inv.Invoke(context.Background(), "adaptive_backend", i)
+	}
+	if got := inv.AdaptiveLimit("adaptive_backend"); got != 8 {
+		t.Errorf("Expected limit to be capped at 8, got %d", got)
+	}
+}
+
+func TestInvokeMultipleDeterministicMode(t *testing.T) {
+	core.SetDeterministicMode(true)
+	defer core.SetDeterministicMode(false)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) core.InvokeFunc[int, int] {
+		return func(ctx context.Context, input int) (int, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return input + 1, nil
+		}
+	}
+	for _, name := range []string{"det_c", "det_a", "det_b"} {
+		registry.RegisterOrReplace(name, record(name))
+	}
+
+	inv := invoker.NewInvoker[int, int]()
+	requests := map[string]int{"det_a": 1, "det_b": 2, "det_c": 3}
+
+	for run := 0; run < 5; run++ {
+		order = nil
+		results := inv.InvokeMultiple(context.Background(), requests)
+
+		if len(order) != 3 || order[0] != "det_a" || order[1] != "det_b" || order[2] != "det_c" {
+			t.Fatalf("Run %d: expected stable order [det_a det_b det_c], got %v", run, order)
+		}
+		if results["det_b"].Output != 3 {
+			t.Errorf("Run %d: expected det_b output 3, got %d", run, results["det_b"].Output)
+		}
+	}
+}
+
+func TestStagePipe(t *testing.T) {
+	registry.RegisterOrReplace("stage_int_to_string", func(ctx context.Context, input int) (string, error) {
+		return strconv.Itoa(input), nil
+	})
+	registry.RegisterOrReplace("string_to_int", func(ctx context.Context, input string) (int, error) {
+		return strconv.Atoi(input)
+	})
+
+	toString := invoker.NewStage(invoker.NewInvoker[int, string](), "stage_int_to_string")
+	toInt := invoker.NewStage(invoker.NewInvoker[string, int](), "string_to_int")
+	double := invoker.NewStage(invoker.NewInvoker[int, int](), "math_double")
+
+	pipeline := invoker.Pipe(invoker.Pipe(toString, toInt), double)
+
+	result, err := pipeline.Invoke(context.Background(), 21)
+	if err != nil {
+		t.Fatalf("Pipeline failed: %v", err)
+	}
+	if result.Output != 42 {
+		t.Errorf("Expected 42, got %d", result.Output)
+	}
+	if names := pipeline.Names(); len(names) != 3 || names[1] != "string_to_int" {
+		t.Errorf("Unexpected stage names: %v", names)
+	}
+
+	failing := invoker.Pipe(toInt, double)
+	if _, err := failing.Invoke(context.Background(), "not a number"); err == nil {
+		t.Error("Expected pipeline error for invalid input")
+	}
+}
+
+func TestTypedInvocationErrors(t *testing.T) {
+	inv := invoker.NewInvoker[int, int]()
+	ctx := context.Background()
+
+	classify := func(err error) string {
+		switch {
+		case err == nil:
+			return "ok"
+		case errors.Is(err, core.ErrLambdaNotFound):
+			return "not_found"
+		case errors.Is(err, core.ErrTimeout):
+			return "timeout"
+		case errors.Is(err, core.ErrRateLimited):
+			return "rate_limited"
+		default:
+			return "handler"
+		}
+	}
+
+	_, err := inv.Invoke(ctx, "no_such_lambda", 1)
+	if got := classify(err); got != "not_found" {
+		t.Errorf("Expected not_found, got %s (%v)", got, err)
+	}
+
+	_, err = inv.Invoke(ctx, "math_factorial", -1)
+	if got := classify(err); got != "handler" {
+		t.Errorf("Expected handler error, got %s (%v)", got, err)
+	}
+
+	registry.RegisterOrReplace("typed_slow", func(ctx context.Context, input int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, core.WithTimeout(10*time.Millisecond))
+	_, err = inv.Invoke(ctx, "typed_slow", 1)
+	if got := classify(err); got != "timeout" {
+		t.Errorf("Expected timeout, got %s (%v)", got, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected timeout to also match context.DeadlineExceeded, got %v", err)
+	}
+
+	limited := core.NewLambdaWithMiddleware("typed_limited",
+		func(ctx context.Context, input int) (int, error) { return input, nil },
+		core.RateLimit[int, int](core.NewRateLimiter(1, time.Minute)),
+	)
+	limited.Invoke(ctx, 1)
+	_, err = limited.Invoke(ctx, 2)
+	if got := classify(err); got != "rate_limited" {
+		t.Errorf("Expected rate_limited, got %s (%v)", got, err)
+	}
+
+	timed := core.NewLambdaWithMiddleware("typed_middleware_timeout",
+		func(ctx context.Context, input int) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+		core.Timeout[int, int](10*time.Millisecond),
+	)
+	_, err = timed.Invoke(ctx, 1)
+	if got := classify(err); got != "timeout" {
+		t.Errorf("Expected middleware timeout, got %s (%v)", got, err)
+	}
+}
+
+func TestDrainerShutdown(t *testing.T) {
+	release := make(chan struct{})
+	var completed int32
+	registry.RegisterOrReplace("drain_slow", func(ctx context.Context, input int) (int, error) {
+		<-release
+		atomic.AddInt32(&completed, 1)
+		return input, nil
+	})
+
+	drainer := invoker.NewDrainer()
+	inv := invoker.NewInvoker[int, int]().WithDrainer(drainer)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if _, err := inv.Invoke(context.Background(), "drain_slow", n); err != nil {
+				t.Errorf("In-flight invocation failed: %v", err)
+			}
+		}(i)
+	}
+	for drainer.Active() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 截止时间前未完成时返回 ctx 错误
+	shortCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := drainer.Shutdown(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded while draining, got %v", err)
+	}
+
+	if _, err := inv.Invoke(context.Background(), "drain_slow", 9); !errors.Is(err, invoker.ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown for new invocation, got %v", err)
+	}
+
+	close(release)
+	ctx, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := drainer.Shutdown(ctx); err != nil {
+		t.Errorf("Expected drain to complete, got %v", err)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&completed) != 3 {
+		t.Errorf("Expected 3 in-flight invocations to complete, got %d", completed)
+	}
+}
+
+func TestInvokeWithPriority(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []int
+
+	registry.RegisterOrReplace("priority_work", func(ctx context.Context, input int) (int, error) {
+		if input == 0 {
+			close(started)
+			<-release
+			return 0, nil
+		}
+		mu.Lock()
+		order = append(order, input)
+		mu.Unlock()
+		return input, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]().WithConcurrency(1)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		inv.Invoke(ctx, "priority_work", 0)
+	}()
+	// 等待阻塞调用占用唯一的名额
+	<-started
+
+	// 以不同优先级排队，输入值为 优先级*10+排队序号
+	for i, priority := range []int{1, 5, 3, 5} {
+		wg.Add(1)
+		input := priority*10 + i
+		go func(p int) {
+			defer wg.Done()
+			inv.InvokeWithPriority(ctx, "priority_work", input, p)
+		}(priority)
+		for inv.Waiting() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+
+	expected := []int{51, 53, 32, 10}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected admission order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestChainWithBudget(t *testing.T) {
+	var steps int32
+	registry.RegisterOrReplace("budget_step", func(ctx context.Context, input int) (int, error) {
+		atomic.AddInt32(&steps, 1)
+		select {
+		case <-time.After(40 * time.Millisecond):
+			return input + 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+
+	ctx, cancel := invoker.WithBudget(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := invoker.ChainWithBudget(ctx, []invoker.ChainStep[int, int]{
+		{Name: "budget_step", Input: 0},
+		{Name: "budget_step"},
+		{Name: "budget_step"},
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, invoker.ErrBudgetExhausted) {
+		t.Fatalf("Expected ErrBudgetExhausted, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("Expected third step to be cut off, got %v", err)
+	}
+	if atomic.LoadInt32(&steps) != 3 {
+		t.Errorf("Expected 3 steps to start, got %d", steps)
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("Expected chain to stop near the 100ms budget, took %v", elapsed)
+	}
+
+	// 预算用完后不再开始新的步骤
+	inv := invoker.NewInvoker[int, int]()
+	results, err := inv.PipelineWithBudget(ctx, "budget_step", []int{1, 2})
+	if !errors.Is(err, invoker.ErrBudgetExhausted) || len(results) != 0 {
+		t.Errorf("Expected exhausted budget to fail fast, got %v results and %v", len(results), err)
+	}
+	if atomic.LoadInt32(&steps) != 3 {
+		t.Errorf("Expected no new steps after exhaustion, got %d", steps)
+	}
+}
+
+func TestInvokeQueuedWorker(t *testing.T) {
+	var failures int32
+	registry.RegisterOrReplace("queued_square", func(ctx context.Context, input int) (int, error) {
+		// 第一次处理 3 时失败，验证重新投递
+		if input == 3 && atomic.AddInt32(&failures, 1) == 1 {
+			return 0, errors.New("transient failure")
+		}
+		return input * input, nil
+	})
+
+	queue := invoker.NewMemoryQueue()
+	inv := invoker.NewInvoker[int, int]().WithQueue(queue)
+
+	ctx := context.Background()
+	for _, input := range []int{1, 2, 3} {
+		if _, err := inv.InvokeQueued(ctx, "queued_square", input); err != nil {
+			t.Fatalf("InvokeQueued failed: %v", err)
+		}
+	}
+	if _, err := inv.InvokeQueued(ctx, "no_such_lambda", 1); !errors.Is(err, core.ErrLambdaNotFound) {
+		t.Errorf("Expected ErrLambdaNotFound, got %v", err)
+	}
+
+	var mu sync.Mutex
+	outputs := make(map[int]int)
+	done := make(chan struct{})
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		inv.RunQueueWorker(workerCtx, 3, func(job invoker.QueuedJob, result *core.LambdaResult[int], err error) {
+			if err != nil {
+				t.Errorf("Job %s failed: %v", job.ID, err)
+				return
+			}
+			mu.Lock()
+			outputs[result.Output] = job.Attempts
+			if len(outputs) == 3 {
+				close(done)
+			}
+			mu.Unlock()
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for worker")
+	}
+
+	if outputs[1] != 1 || outputs[4] != 1 || outputs[9] != 2 {
+		t.Errorf("Expected outputs {1:1 4:1 9:2} (output:attempts), got %v", outputs)
+	}
+	if pending, inflight := queue.Len(); pending != 0 || inflight != 0 {
+		t.Errorf("Expected queue to be drained, got %d pending and %d in flight", pending, inflight)
+	}
+}
+
+func TestMemoryQueueRedeliversUnacked(t *testing.T) {
+	queue := invoker.NewMemoryQueue()
+	ctx := context.Background()
+
+	queue.Enqueue(ctx, invoker.QueuedJob{ID: "a", Name: "job"})
+	job, err := queue.Dequeue(ctx)
+	if err != nil || job.ID != "a" {
+		t.Fatalf("Expected job a, got %+v (err=%v)", job, err)
+	}
+
+	// 未确认即“崩溃”，恢复后任务重新投递
+	if recovered := queue.Recover(); recovered != 1 {
+		t.Fatalf("Expected 1 recovered job, got %d", recovered)
+	}
+	job, err = queue.Dequeue(ctx)
+	if err != nil || job.ID != "a" {
+		t.Fatalf("Expected redelivered job a, got %+v (err=%v)", job, err)
+	}
+	if err := queue.Ack(ctx, "a"); err != nil {
+		t.Errorf("Ack failed: %v", err)
+	}
+}
+
+func TestInvokerAdaptiveTimeoutFromP99(t *testing.T) {
+	registry.RegisterOrReplace("adaptive_sleep", func(ctx context.Context, ms int) (int, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+			return ms, nil
+		}
+	})
+
+	inv := invoker.NewInvoker[int, int]().WithAdaptiveTimeout(3)
+	if _, ok := inv.AdaptiveTimeout("adaptive_sleep"); ok {
+		t.Fatal("Expected no adaptive timeout before warm-up")
+	}
+
+	for i := 0; i < 30; i++ {
+		if _, err := inv.Invoke(context.Background(), "adaptive_sleep", 5); err != nil {
+			t.Fatalf("Warm-up call failed: %v", err)
+		}
+	}
+
+	timeout, ok := inv.AdaptiveTimeout("adaptive_sleep")
+	if !ok {
+		t.Fatal("Expected adaptive timeout after warm-up")
+	}
+	lambda, _ := inv.Get("adaptive_sleep")
+	p99, _ := lambda.Percentile(99)
+	if timeout != time.Duration(float64(p99)*3) || timeout < 15*time.Millisecond {
+		t.Errorf("Expected timeout 3 x p99 (%v), got %v", p99, timeout)
+	}
+
+	start := time.Now()
+	_, err := inv.Invoke(context.Background(), "adaptive_sleep", 500)
+	if !errors.Is(err, core.ErrTimeout) {
+		t.Fatalf("Expected slow call to hit the adaptive timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected call to be cut off near %v, took %v", timeout, elapsed)
+	}
+}
+
+func TestAsyncPathsPropagateContextValues(t *testing.T) {
+	tenantKey := core.NewContextKey[string]("tenant")
+	registry.RegisterOrReplace("ctx_tenant", func(ctx context.Context, input string) (string, error) {
+		tenant, ok := tenantKey.Value(ctx)
+		if !ok {
+			return "", errors.New("tenant missing from context")
+		}
+		return input + "@" + tenant, nil
+	})
+
+	ctx := tenantKey.WithValue(context.Background(), "acme")
+	inv := invoker.NewInvoker[string, string]()
+
+	result := <-inv.InvokeAsync(ctx, "ctx_tenant", "async")
+	if result.Error != nil || result.Output != "async@acme" {
+		t.Errorf("InvokeAsync: expected async@acme, got %q (err=%v)", result.Output, result.Error)
+	}
+
+	done := make(chan *core.LambdaResult[string], 1)
+	inv.InvokeCallback(ctx, "ctx_tenant", "callback", func(r *core.LambdaResult[string]) { done <- r })
+	select {
+	case r := <-done:
+		if r.Error != nil || r.Output != "callback@acme" {
+			t.Errorf("InvokeCallback: expected callback@acme, got %q (err=%v)", r.Output, r.Error)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("InvokeCallback: callback not called")
+	}
+
+	results := inv.InvokeMultiple(ctx, map[string]string{"ctx_tenant": "multiple"})
+	if r := results["ctx_tenant"]; r == nil || r.Error != nil || r.Output != "multiple@acme" {
+		t.Errorf("InvokeMultiple: expected multiple@acme, got %+v", r)
+	}
+
+	each, err := inv.InvokeEach(ctx, "ctx_tenant", []string{"a", "b", "c"}, 2)
+	if err != nil {
+		t.Fatalf("InvokeEach failed: %v", err)
+	}
+	for i, r := range each {
+		if r.Error != nil || !strings.HasSuffix(r.Output, "@acme") {
+			t.Errorf("InvokeEach[%d]: expected tenant suffix, got %q (err=%v)", i, r.Output, r.Error)
+		}
+	}
+
+	// Async 中间件的副作用在工作池中执行，context 与请求解除取消关联但保留值
+	seen := make(chan string, 1)
+	lambda := core.NewLambdaWithMiddleware("ctx_tenant_async", func(ctx context.Context, input string) (string, error) {
+		return input, nil
+	}, core.Async[string, string](func(ctx context.Context, input, output string, err error) {
+		tenant, _ := tenantKey.Value(ctx)
+		seen <- tenant
+	}))
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	lambda.Invoke(cancelCtx, "side effect")
+	cancel()
+	select {
+	case tenant := <-seen:
+		if tenant != "acme" {
+			t.Errorf("Async: expected tenant acme, got %q", tenant)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Async: side effect not run")
+	}
+}
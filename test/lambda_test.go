@@ -2,11 +2,14 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ZHLX2005/minilambda/core"
 	"github.com/ZHLX2005/minilambda/invoker"
 	"github.com/ZHLX2005/minilambda/registry"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -401,3 +404,559 @@ func TestLambdaMetrics(t *testing.T) {
 		t.Errorf("Expected 0 error invocations, got %d", metrics.ErrorInvocations)
 	}
 }
+
+func TestPartialApplication(t *testing.T) {
+	add := core.NewLambda("pair_add", func(ctx context.Context, input core.Pair[int, int]) (int, error) {
+		return input.First + input.Second, nil
+	})
+
+	addTen := core.Partial(add, 10)
+
+	result, err := addTen.Invoke(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Partial lambda invocation failed: %v", err)
+	}
+	if result.Output != 15 {
+		t.Errorf("Expected 15, got %d", result.Output)
+	}
+	if addTen.GetName() != "pair_add" {
+		t.Errorf("Expected name 'pair_add', got '%s'", addTen.GetName())
+	}
+}
+
+func TestWithRecoverOption(t *testing.T) {
+	lambda := core.NewLambda("test_recover", func(ctx context.Context, input int) (int, error) {
+		panic("handler exploded")
+	}, core.WithRecover())
+
+	result, err := lambda.Invoke(context.Background(), 1)
+	if err == nil {
+		t.Fatal("Expected panic to be converted to an error")
+	}
+	if !strings.Contains(err.Error(), "handler exploded") {
+		t.Errorf("Expected error to mention panic value, got %v", err)
+	}
+	if result.Error == nil {
+		t.Error("Expected result to carry the error")
+	}
+
+	metrics := lambda.GetMetrics()
+	if metrics.ErrorInvocations != 1 {
+		t.Errorf("Expected 1 error invocation, got %d", metrics.ErrorInvocations)
+	}
+}
+
+func TestCancelledContextSkipsHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	handler := func(ctx context.Context, input int) (int, error) {
+		called = true
+		return input, nil
+	}
+
+	lambda := core.NewLambda("test_cancelled", handler)
+	if _, err := lambda.Invoke(ctx, 1); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if metrics := lambda.GetMetrics(); metrics.ErrorInvocations != 1 {
+		t.Errorf("Expected 1 error invocation, got %d", metrics.ErrorInvocations)
+	}
+
+	chain := core.NewChain[int, int](handler)
+	if _, err := chain.Execute(ctx, 1); err != context.Canceled {
+		t.Errorf("Expected context.Canceled from chain, got %v", err)
+	}
+
+	if called {
+		t.Error("Expected handler not to run with a cancelled context")
+	}
+}
+
+func TestWithIsolatedExecution(t *testing.T) {
+	lambda := core.NewLambda("test_isolated", func(ctx context.Context, input int) (int, error) {
+		if input < 0 {
+			panic("negative input")
+		}
+		return input * 2, nil
+	}, core.WithIsolatedExecution())
+
+	result, err := lambda.Invoke(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Output != 8 {
+		t.Errorf("Expected 8, got %d", result.Output)
+	}
+
+	_, err = lambda.Invoke(context.Background(), -1)
+	if err == nil || !strings.Contains(err.Error(), "negative input") {
+		t.Errorf("Expected panic to be returned as error, got %v", err)
+	}
+}
+
+func TestSinceNeverNegative(t *testing.T) {
+	// 模拟墙上时钟回拨：起始时间位于未来且不带单调时钟读数
+	future := time.Now().Round(0).Add(time.Hour)
+	if d := core.Since(future); d != 0 {
+		t.Errorf("Expected 0 for a start time in the future, got %v", d)
+	}
+
+	start := time.Now()
+	time.Sleep(time.Millisecond)
+	if d := core.Since(start); d <= 0 {
+		t.Errorf("Expected positive duration, got %v", d)
+	}
+
+	lambda := core.NewLambda("test_duration", func(ctx context.Context, input int) (int, error) {
+		return input, nil
+	})
+	for i := 0; i < 100; i++ {
+		result, _ := lambda.Invoke(context.Background(), i)
+		if result.Duration < 0 {
+			t.Fatalf("Expected non-negative duration, got %v", result.Duration)
+		}
+	}
+}
+
+func TestGlobalConcurrencyLimit(t *testing.T) {
+	core.SetGlobalConcurrencyLimit(2)
+	defer core.SetGlobalConcurrencyLimit(0)
+
+	var inFlight, maxInFlight int32
+	track := func(ctx context.Context, input int) (int, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return input, nil
+	}
+
+	registry.RegisterOrReplace("global_limit_a", track)
+	registry.RegisterOrReplace("global_limit_b", track)
+	withMiddleware := core.NewLambdaWithMiddleware("global_limit_c", track)
+
+	invA := invoker.NewInvoker[int, int]()
+	invB := invoker.NewInvoker[int, int]().WithConcurrency(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func(n int) {
+			defer wg.Done()
+			invA.Invoke(context.Background(), "global_limit_a", n)
+		}(i)
+		go func(n int) {
+			defer wg.Done()
+			invB.Invoke(context.Background(), "global_limit_b", n)
+		}(i)
+		go func(n int) {
+			defer wg.Done()
+			withMiddleware.Invoke(context.Background(), n)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("Expected at most 2 concurrent lambdas, got %d", got)
+	}
+}
+
+func TestLambdaLifecycleHooks(t *testing.T) {
+	var events []string
+	var successDuration, errorDuration time.Duration
+	var hookErr error
+
+	lambda := core.NewLambda("hooked",
+		func(ctx context.Context, input int) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			if input < 0 {
+				return 0, errors.New("negative input")
+			}
+			return input, nil
+		},
+		core.WithOnStart(func(ctx context.Context) { events = append(events, "start1") }),
+		core.WithOnStart(func(ctx context.Context) { events = append(events, "start2") }),
+		core.WithOnSuccess(func(ctx context.Context, d time.Duration) {
+			events = append(events, "success")
+			successDuration = d
+		}),
+		core.WithOnError(func(ctx context.Context, err error, d time.Duration) {
+			events = append(events, "error")
+			hookErr = err
+			errorDuration = d
+		}),
+	)
+
+	result, err := lambda.Invoke(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Invocation failed: %v", err)
+	}
+	if strings.Join(events, ",") != "start1,start2,success" {
+		t.Errorf("Unexpected hook sequence on success: %v", events)
+	}
+	if successDuration != result.Duration || successDuration < 10*time.Millisecond {
+		t.Errorf("Expected success duration %v, got %v", result.Duration, successDuration)
+	}
+
+	events = nil
+	result, _ = lambda.Invoke(context.Background(), -1)
+	if strings.Join(events, ",") != "start1,start2,error" {
+		t.Errorf("Unexpected hook sequence on error: %v", events)
+	}
+	if hookErr == nil || hookErr.Error() != "negative input" {
+		t.Errorf("Expected hook to receive handler error, got %v", hookErr)
+	}
+	if errorDuration != result.Duration {
+		t.Errorf("Expected error duration %v, got %v", result.Duration, errorDuration)
+	}
+}
+
+func TestDerivedLambdaHooksAreIndependent(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+	}
+
+	// 三个钩子使底层数组留有空余容量，派生时的追加若不截断容量就会写入同一位置
+	base := core.NewLambda("hook_base",
+		func(ctx context.Context, input int) (int, error) { return input, nil },
+		core.WithOnStart(record("base1")),
+		core.WithOnStart(record("base2")),
+		core.WithOnStart(record("base3")),
+	)
+	first := base.WithOptions(core.WithOnStart(record("first")))
+	second := base.WithOptions(core.WithOnStart(record("second")))
+
+	for _, tc := range []struct {
+		lambda   *core.Lambda[int, int]
+		expected string
+	}{
+		{first, "base1,base2,base3,first"},
+		{second, "base1,base2,base3,second"},
+		{base, "base1,base2,base3"},
+	} {
+		events = nil
+		if _, err := tc.lambda.Invoke(context.Background(), 1); err != nil {
+			t.Fatalf("Invocation failed: %v", err)
+		}
+		if got := strings.Join(events, ","); got != tc.expected {
+			t.Errorf("Expected hooks %s, got %s", tc.expected, got)
+		}
+	}
+}
+
+func TestOptionPresets(t *testing.T) {
+	handler := func(ctx context.Context, input int) (int, error) { return input, nil }
+
+	resilient := core.NewLambda("preset_resilient", handler, core.ResilientPreset()).GetOptions()
+	if resilient.Timeout != 30*time.Second || resilient.Retries != 3 || !resilient.EnableMetrics || !resilient.Recover {
+		t.Errorf("Unexpected resilient options: %+v", resilient)
+	}
+
+	fast := core.NewLambda("preset_fast", handler, core.FastPreset()).GetOptions()
+	if fast.Timeout != time.Second || fast.Retries != 0 || fast.EnableMetrics {
+		t.Errorf("Unexpected fast options: %+v", fast)
+	}
+
+	// 自定义预设，后面的选项覆盖预设中的同名设置
+	custom := core.OptionPreset(core.WithTimeout(5*time.Second), core.WithRetries(2), core.WithTags("batch"))
+	options := core.NewLambda("preset_custom", handler, custom, core.WithRetries(4)).GetOptions()
+	if options.Timeout != 5*time.Second || options.Retries != 4 || len(options.Tags) != 1 || options.Tags[0] != "batch" {
+		t.Errorf("Unexpected custom preset options: %+v", options)
+	}
+}
+
+func TestMetricsAverageAndStdDev(t *testing.T) {
+	lambda := core.NewLambda("metrics_stats", func(ctx context.Context, input int) (int, error) {
+		return input, nil
+	})
+
+	for _, d := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond} {
+		lambda.MergeMetrics(&core.LambdaMetrics{
+			TotalInvocations:   1,
+			SuccessInvocations: 1,
+			TotalDuration:      d,
+			SumSquares:         float64(d) * float64(d),
+		})
+	}
+
+	metrics := lambda.GetMetrics()
+	if metrics.AverageDuration != 2*time.Millisecond {
+		t.Errorf("Expected average 2ms, got %v", metrics.AverageDuration)
+	}
+	// 总体标准差 sqrt(2/3) ms ≈ 816.497µs
+	if metrics.StdDevDuration < 816*time.Microsecond || metrics.StdDevDuration > 817*time.Microsecond {
+		t.Errorf("Expected stddev ~816µs, got %v", metrics.StdDevDuration)
+	}
+}
+
+func TestMetricsAverageNotTruncated(t *testing.T) {
+	lambda := core.NewLambda("metrics_round", func(ctx context.Context, input int) (int, error) {
+		return input, nil
+	})
+
+	lambda.MergeMetrics(&core.LambdaMetrics{TotalInvocations: 3, TotalDuration: 5})
+
+	// 5ns / 3 = 1.67ns，整数除法会截断为 1ns
+	if avg := lambda.GetMetrics().AverageDuration; avg != 2 {
+		t.Errorf("Expected rounded average 2ns, got %v", avg)
+	}
+}
+
+func TestMetricsCountTimeoutsSeparately(t *testing.T) {
+	slow := func(ctx context.Context, input int) (int, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			return input, nil
+		}
+	}
+
+	lambda := core.NewLambda("metrics_timeout", func(ctx context.Context, input int) (int, error) {
+		if input < 0 {
+			return 0, errors.New("negative input")
+		}
+		return slow(ctx, input)
+	}, core.WithTimeout(10*time.Millisecond))
+
+	if _, err := lambda.Invoke(context.Background(), 1); !errors.Is(err, core.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	lambda.Invoke(context.Background(), -1)
+
+	metrics := lambda.GetMetrics()
+	if metrics.TimeoutInvocations != 1 {
+		t.Errorf("Expected 1 timeout, got %d", metrics.TimeoutInvocations)
+	}
+	if metrics.ErrorInvocations != 2 {
+		t.Errorf("Expected 2 errors, got %d", metrics.ErrorInvocations)
+	}
+
+	// Timeout 中间件返回的错误同样被 Metrics 中间件归类为超时
+	mwMetrics := &core.LambdaMetrics{}
+	mwLambda := core.NewLambdaWithMiddleware("metrics_timeout_mw", slow,
+		core.Metrics[int, int](mwMetrics),
+		core.Timeout[int, int](10*time.Millisecond),
+	)
+	if _, err := mwLambda.Invoke(context.Background(), 1); !errors.Is(err, core.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	if mwMetrics.TimeoutInvocations != 1 || mwMetrics.ErrorInvocations != 1 {
+		t.Errorf("Expected 1 timeout error, got %+v", mwMetrics)
+	}
+}
+
+func TestResultReportsRetryAttempts(t *testing.T) {
+	var calls int32
+	lambda := core.NewLambda("flaky_twice", func(ctx context.Context, input int) (int, error) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return 0, errors.New("transient failure")
+		}
+		return input, nil
+	}, core.WithRetries(3))
+
+	result, err := lambda.Invoke(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", result.Attempts)
+	}
+
+	metrics := lambda.GetMetrics()
+	if metrics.RetryInvocations != 1 || metrics.TotalRetries != 2 {
+		t.Errorf("Expected 1 retried invocation with 2 retries, got %d/%d", metrics.RetryInvocations, metrics.TotalRetries)
+	}
+
+	// Retry 中间件通过结果元数据上报尝试次数，处理器可读取当前尝试序号
+	var seen []int
+	calls = 0
+	mwLambda := core.NewLambdaWithMiddleware("flaky_twice_mw", func(ctx context.Context, input int) (int, error) {
+		attempt, _ := core.RetryAttemptKey.Value(ctx)
+		seen = append(seen, attempt)
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return 0, errors.New("transient failure")
+		}
+		return input, nil
+	}, core.Retry[int, int](3))
+
+	mwResult, err := mwLambda.Invoke(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mwResult.Attempts != 3 {
+		t.Errorf("Expected 3 attempts from Retry middleware, got %d", mwResult.Attempts)
+	}
+	if len(seen) != 3 || seen[0] != 1 || seen[2] != 3 {
+		t.Errorf("Expected attempt numbers [1 2 3], got %v", seen)
+	}
+}
+
+func TestLambdaConfigRetryPolicy(t *testing.T) {
+	var calls int32
+	handler := func(ctx context.Context, input int) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return 0, errors.New("transient failure")
+		}
+		return input, nil
+	}
+
+	chain := core.NewChain(handler, core.RetryFromConfig[int, int]())
+	lambda := core.NewLambda("configured_retry", chain.Execute,
+		core.WithConfig(core.RetryPolicyKey{}, core.RetryPolicy{MaxRetries: 2}),
+	)
+
+	value, ok := lambda.Config(core.RetryPolicyKey{})
+	if !ok {
+		t.Fatal("Expected retry policy in config")
+	}
+	if policy, ok := value.(core.RetryPolicy); !ok || policy.MaxRetries != 2 {
+		t.Errorf("Expected RetryPolicy{MaxRetries: 2}, got %#v", value)
+	}
+
+	result, err := lambda.Invoke(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Expected retry from configured policy to succeed: %v", err)
+	}
+	if result.Output != 5 || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected output 5 after 2 calls, got %d after %d", result.Output, calls)
+	}
+
+	// WithOptions 覆盖配置不影响原lambda
+	other := lambda.WithOptions(core.WithConfig(core.RetryPolicyKey{}, core.RetryPolicy{MaxRetries: 0}))
+	if value, _ := other.Config(core.RetryPolicyKey{}); value.(core.RetryPolicy).MaxRetries != 0 {
+		t.Errorf("Expected overridden policy, got %#v", value)
+	}
+	if value, _ := lambda.Config(core.RetryPolicyKey{}); value.(core.RetryPolicy).MaxRetries != 2 {
+		t.Errorf("Expected original policy to be unchanged, got %#v", value)
+	}
+}
+
+func TestInvokeWithOptionsOverridesPerCall(t *testing.T) {
+	lambda := core.NewLambda("per_call_options", func(ctx context.Context, input int) (int, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return input, nil
+		}
+	}, core.WithTimeout(time.Second))
+
+	if _, err := lambda.InvokeWithOptions(context.Background(), 1, core.WithTimeout(time.Millisecond)); !errors.Is(err, core.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout with 1ms override, got %v", err)
+	}
+
+	result, err := lambda.Invoke(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Expected normal invocation to succeed: %v", err)
+	}
+	if result.Output != 2 {
+		t.Errorf("Expected 2, got %d", result.Output)
+	}
+
+	if timeout := lambda.GetOptions().Timeout; timeout != time.Second {
+		t.Errorf("Expected stored timeout to stay 1s, got %v", timeout)
+	}
+	metrics := lambda.GetMetrics()
+	if metrics.TotalInvocations != 2 || metrics.TimeoutInvocations != 1 {
+		t.Errorf("Expected both calls in shared metrics, got total=%d timeouts=%d", metrics.TotalInvocations, metrics.TimeoutInvocations)
+	}
+}
+
+func TestSubscribeMetricsSnapshots(t *testing.T) {
+	lambda := core.NewLambda("metrics_subscribe", func(ctx context.Context, input int) (int, error) {
+		return input, nil
+	})
+
+	updates, cancel := lambda.Subscribe()
+
+	var last int64
+	for i := 1; i <= 3; i++ {
+		lambda.Invoke(context.Background(), i)
+
+		select {
+		case snapshot := <-updates:
+			if snapshot.TotalInvocations <= last {
+				t.Errorf("Expected increasing totals, got %d after %d", snapshot.TotalInvocations, last)
+			}
+			last = snapshot.TotalInvocations
+		case <-time.After(time.Second):
+			t.Fatalf("Expected snapshot after invocation %d", i)
+		}
+	}
+	if last != 3 {
+		t.Errorf("Expected final total 3, got %d", last)
+	}
+
+	// 未读取的快照被合并，只保留最新的一份
+	for i := 0; i < 5; i++ {
+		lambda.Invoke(context.Background(), i)
+	}
+	if snapshot := <-updates; snapshot.TotalInvocations != 8 {
+		t.Errorf("Expected coalesced snapshot with total 8, got %d", snapshot.TotalInvocations)
+	}
+
+	cancel()
+	if _, ok := <-updates; ok {
+		t.Error("Expected channel to be closed after cancel")
+	}
+	lambda.Invoke(context.Background(), 1)
+	cancel()
+}
+
+func TestResultMapAndOrElse(t *testing.T) {
+	inv := invoker.NewInvoker[int, int]()
+	double := func(x int) int { return x * 2 }
+
+	ok := core.ResultOf(inv.Invoke(context.Background(), "math_double", 5)).Map(double)
+	if output, err := ok.Value(); err != nil || output != 20 {
+		t.Errorf("Expected Map on success to give 20, got %d (err=%v)", output, err)
+	}
+
+	called := false
+	failed := core.ResultOf(inv.Invoke(context.Background(), "math_factorial", -1)).Map(func(x int) int {
+		called = true
+		return x
+	})
+	if called {
+		t.Error("Expected Map to be skipped on error")
+	}
+	if failed.IsOk() || failed.Err() == nil {
+		t.Error("Expected error to propagate through Map")
+	}
+	if got := failed.OrElse(-1); got != -1 {
+		t.Errorf("Expected OrElse default -1, got %d", got)
+	}
+	if got := ok.OrElse(-1); got != 20 {
+		t.Errorf("Expected OrElse to return output 20, got %d", got)
+	}
+
+	errOdd := errors.New("odd")
+	evenOnly := func(x int) core.Result[int] {
+		if x%2 != 0 {
+			return core.Fail[int](errOdd)
+		}
+		return core.Ok(x / 2)
+	}
+	if r := core.Ok(3).FlatMap(evenOnly); !errors.Is(r.Err(), errOdd) {
+		t.Errorf("Expected FlatMap failure, got %v", r.Err())
+	}
+
+	label := core.MapResult(ok, func(x int) string { return fmt.Sprintf("n=%d", x) })
+	if s := label.OrElse("none"); s != "n=20" {
+		t.Errorf("Expected n=20, got %q", s)
+	}
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/lambdactl"
+)
+
+func TestLambdactlInvoke(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := lambdactl.Run([]string{"invoke", "string_upper", "--timeout", "1s"}, strings.NewReader(`"cli"`), &stdout, &stderr)
+	if code != lambdactl.ExitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != `"CLI"` {
+		t.Errorf("Expected \"CLI\", got %s", stdout.String())
+	}
+}
+
+func TestLambdactlInvokeErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := lambdactl.Run([]string{"invoke", "no_such_lambda"}, strings.NewReader(`1`), &stdout, &stderr); code != lambdactl.ExitError {
+		t.Errorf("Expected exit code 1 for missing lambda, got %d", code)
+	}
+	if code := lambdactl.Run([]string{"invoke", "math_factorial"}, strings.NewReader(`-3`), &stdout, &stderr); code != lambdactl.ExitError {
+		t.Errorf("Expected exit code 1 for handler error, got %d", code)
+	}
+	if code := lambdactl.Run([]string{"invoke"}, strings.NewReader(``), &stdout, &stderr); code != lambdactl.ExitUsage {
+		t.Errorf("Expected exit code 2 for missing name, got %d", code)
+	}
+	if code := lambdactl.Run([]string{"invoke", "string_upper", "extra"}, strings.NewReader(`"x"`), &stdout, &stderr); code != lambdactl.ExitUsage {
+		t.Errorf("Expected usage exit code for extra arguments, got %d", code)
+	}
+}
+
+func TestLambdactlList(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := lambdactl.Run([]string{"--list"}, strings.NewReader(""), &stdout, &stderr); code != lambdactl.ExitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	output := stdout.String()
+	for _, name := range []string{"string_upper", "math_double", "validate_person"} {
+		if !strings.Contains(output, name) {
+			t.Errorf("Expected list output to contain %s", name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/natsbridge"
+)
+
+// fakeConn 进程内的 natsbridge.Conn 实现，同步投递消息
+type fakeConn struct {
+	mu        sync.Mutex
+	handlers  map[string]func(*natsbridge.Msg)
+	published map[string][][]byte
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		handlers:  make(map[string]func(*natsbridge.Msg)),
+		published: make(map[string][][]byte),
+	}
+}
+
+type fakeSubscription struct {
+	conn    *fakeConn
+	subject string
+}
+
+func (s fakeSubscription) Unsubscribe() error {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+	delete(s.conn.handlers, s.subject)
+	return nil
+}
+
+func (c *fakeConn) Subscribe(subject string, handler func(*natsbridge.Msg)) (natsbridge.Subscription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[subject] = handler
+	return fakeSubscription{conn: c, subject: subject}, nil
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+	c.mu.Lock()
+	c.published[subject] = append(c.published[subject], data)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeConn) deliver(msg *natsbridge.Msg) {
+	c.mu.Lock()
+	handler := c.handlers[msg.Subject]
+	c.mu.Unlock()
+	if handler != nil {
+		handler(msg)
+	}
+}
+
+func TestNATSConsumePublishesReply(t *testing.T) {
+	conn := newFakeConn()
+	sub, err := natsbridge.Consume[int, int](conn, "jobs.double", "math_double", true)
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	conn.deliver(&natsbridge.Msg{Subject: "jobs.double", Reply: "inbox.1", Data: []byte("21")})
+
+	replies := conn.published["inbox.1"]
+	if len(replies) != 1 || string(replies[0]) != "42" {
+		t.Errorf("Expected reply 42, got %q", replies)
+	}
+
+	// 解码失败与lambda错误都进入死信主题
+	conn.deliver(&natsbridge.Msg{Subject: "jobs.double", Reply: "inbox.2", Data: []byte(`"not a number"`)})
+	sub.Unsubscribe()
+	conn.deliver(&natsbridge.Msg{Subject: "jobs.double", Reply: "inbox.3", Data: []byte("1")})
+
+	natsbridge.Consume[int, int](conn, "jobs.factorial", "math_factorial", true, natsbridge.WithDeadLetterSubject("jobs.failed"))
+	conn.deliver(&natsbridge.Msg{Subject: "jobs.factorial", Reply: "inbox.4", Data: []byte("-1")})
+
+	if len(conn.published["inbox.2"]) != 0 || len(conn.published["inbox.3"]) != 0 || len(conn.published["inbox.4"]) != 0 {
+		t.Errorf("Expected no replies for failed or unsubscribed messages, got %v", conn.published)
+	}
+
+	dead := conn.published[natsbridge.DeadLetterSubject("jobs.double")]
+	if len(dead) != 1 {
+		t.Fatalf("Expected 1 dead letter for decode failure, got %d", len(dead))
+	}
+	var letter natsbridge.DeadLetter
+	if err := json.Unmarshal(dead[0], &letter); err != nil {
+		t.Fatalf("Failed to decode dead letter: %v", err)
+	}
+	if letter.Lambda != "math_double" || string(letter.Data) != `"not a number"` || !strings.Contains(letter.Error, "decode") {
+		t.Errorf("Unexpected dead letter: %+v", letter)
+	}
+
+	if failed := conn.published["jobs.failed"]; len(failed) != 1 {
+		t.Errorf("Expected handler error on custom dead-letter subject, got %d", len(failed))
+	}
+}
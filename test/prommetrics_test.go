@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/prommetrics"
+)
+
+func TestPrometheusMetricsScrape(t *testing.T) {
+	exporter := prommetrics.NewExporter()
+
+	handler := func(ctx context.Context, input int) (int, error) {
+		if input < 0 {
+			return 0, errors.New("negative input")
+		}
+		return input, nil
+	}
+
+	lambda := core.NewLambdaWithMiddleware("prom_lambda", handler, prommetrics.Middleware[int, int](exporter, "prom_lambda"))
+	for _, input := range []int{1, 2, -1} {
+		lambda.Invoke(context.Background(), input)
+	}
+
+	server := httptest.NewServer(exporter.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	output := string(body)
+
+	expected := []string{
+		`minilambda_invocations_total{lambda="prom_lambda"} 3`,
+		`minilambda_errors_total{lambda="prom_lambda"} 1`,
+		`minilambda_duration_seconds_bucket{lambda="prom_lambda",le="+Inf"} 3`,
+		`minilambda_duration_seconds_count{lambda="prom_lambda"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected scrape output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
@@ -0,0 +1,833 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/invoker"
+	"github.com/ZHLX2005/minilambda/registry"
+)
+
+func TestListAllAcrossTypes(t *testing.T) {
+	all := registry.ListAll()
+
+	found := make(map[string]string)
+	for _, meta := range all {
+		found[meta.Name] = meta.InputType + "->" + meta.OutputType
+	}
+
+	expected := map[string]string{
+		"math_double":     "int->int",
+		"string_upper":    "string->string",
+		"validate_person": "test.Person->test.PersonGreeting",
+	}
+
+	for name, types := range expected {
+		got, exists := found[name]
+		if !exists {
+			t.Errorf("Expected lambda '%s' in ListAll", name)
+			continue
+		}
+		if got != types {
+			t.Errorf("Expected '%s' to be %s, got %s", name, types, got)
+		}
+	}
+}
+
+func TestRegisterOrReplace(t *testing.T) {
+	previous := registry.RegisterOrReplace("math_double", func(ctx context.Context, input int) (int, error) {
+		return input * 3, nil
+	})
+	if previous == nil {
+		t.Fatal("Expected previous math_double lambda to be returned")
+	}
+	defer registry.RegisterOrReplace("math_double", func(ctx context.Context, input int) (int, error) {
+		return input * 2, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]()
+	result, err := inv.Invoke(context.Background(), "math_double", 7)
+	if err != nil {
+		t.Fatalf("Lambda invocation failed: %v", err)
+	}
+	if result.Output != 21 {
+		t.Errorf("Expected replaced lambda to return 21, got %d", result.Output)
+	}
+}
+
+func TestFindByTag(t *testing.T) {
+	identity := func(ctx context.Context, input int) (int, error) { return input, nil }
+	registry.RegisterOrReplace("tagged_a", identity, core.WithTags("billing", "critical"))
+	registry.RegisterOrReplace("tagged_b", identity, core.WithTags("billing"))
+	registry.RegisterOrReplace("tagged_c", identity, core.WithTags("critical"))
+	registry.RegisterOrReplace("tagged_d", func(ctx context.Context, input string) (string, error) {
+		return input, nil
+	}, core.WithTags("critical"))
+
+	names := registry.FindByTag[int, int]("billing")
+	if len(names) != 2 || names[0] != "tagged_a" || names[1] != "tagged_b" {
+		t.Errorf("Expected [tagged_a tagged_b], got %v", names)
+	}
+
+	var critical []string
+	for _, meta := range registry.FindByTagAll("critical") {
+		critical = append(critical, meta.Name)
+	}
+	if len(critical) != 3 || critical[0] != "tagged_a" || critical[1] != "tagged_c" || critical[2] != "tagged_d" {
+		t.Errorf("Expected [tagged_a tagged_c tagged_d], got %v", critical)
+	}
+
+	meta, exists := registry.GetLambdaMeta[int, int]("tagged_a")
+	if !exists {
+		t.Fatal("Expected meta for tagged_a")
+	}
+	if len(meta.Tags) != 2 || meta.Tags[0] != "billing" || meta.Tags[1] != "critical" {
+		t.Errorf("Expected meta tags [billing critical], got %v", meta.Tags)
+	}
+}
+
+func TestCompareLambdas(t *testing.T) {
+	registry.RegisterOrReplace("compare_old", func(ctx context.Context, input int) (int, error) {
+		return input * 2, nil
+	})
+	registry.RegisterOrReplace("compare_new", func(ctx context.Context, input int) (int, error) {
+		if input > 3 {
+			return input * 3, nil
+		}
+		return input + input, nil
+	})
+
+	diffs := registry.CompareLambdas(context.Background(), "compare_old", "compare_new",
+		[]int{1, 2, 3, 4, 5},
+		func(a, b int) bool { return a == b },
+	)
+
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %d: %+v", len(diffs), diffs)
+	}
+	if diffs[0].Input != 4 || diffs[0].OutputA != 8 || diffs[0].OutputB != 12 {
+		t.Errorf("Unexpected first diff: %+v", diffs[0])
+	}
+	if diffs[1].Input != 5 || diffs[1].Index != 4 {
+		t.Errorf("Unexpected second diff: %+v", diffs[1])
+	}
+}
+
+func TestBroadcastByTag(t *testing.T) {
+	var warmed []string
+	var mu sync.Mutex
+	warm := func(name string) {
+		mu.Lock()
+		warmed = append(warmed, name)
+		mu.Unlock()
+	}
+
+	registry.RegisterOrReplace("warm_int", func(ctx context.Context, input int) (int, error) {
+		warm("warm_int")
+		return input, nil
+	}, core.WithTags("group=cache"))
+	registry.RegisterOrReplace("warm_string", func(ctx context.Context, input string) (string, error) {
+		warm("warm_string")
+		return "", errors.New("warm failed")
+	}, core.WithTags("group=cache"))
+	registry.RegisterOrReplace("warm_no_default", func(ctx context.Context, input int) (int, error) {
+		warm("warm_no_default")
+		return input, nil
+	}, core.WithTags("group=cache"))
+
+	registry.SetDefaultInput[int, int]("warm_int", 1)
+	registry.SetDefaultInput[string, string]("warm_string", "ping")
+
+	results := registry.Broadcast(context.Background(), "group", "cache")
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 broadcast results, got %v", results)
+	}
+	if err, ok := results["warm_int"]; !ok || err != nil {
+		t.Errorf("Expected warm_int to succeed, got %v (present=%v)", err, ok)
+	}
+	if err := results["warm_string"]; err == nil || err.Error() != "warm failed" {
+		t.Errorf("Expected warm_string error, got %v", err)
+	}
+	if len(warmed) != 2 {
+		t.Errorf("Expected 2 lambdas invoked, got %v", warmed)
+	}
+}
+
+func TestSaveAndLoadMetrics(t *testing.T) {
+	registry.RegisterOrReplace("metrics_persist", func(ctx context.Context, input int) (int, error) {
+		if input < 0 {
+			return 0, errors.New("negative input")
+		}
+		return input, nil
+	})
+
+	inv := invoker.NewInvoker[int, int]()
+	for _, input := range []int{1, 2, -1} {
+		inv.Invoke(context.Background(), "metrics_persist", input)
+	}
+
+	var buf bytes.Buffer
+	if err := registry.SaveMetrics(&buf); err != nil {
+		t.Fatalf("SaveMetrics failed: %v", err)
+	}
+
+	lambda, _ := registry.GetLambda[int, int]("metrics_persist")
+	lambda.ResetMetrics()
+	if metrics := lambda.GetMetrics(); metrics.TotalInvocations != 0 {
+		t.Fatalf("Expected metrics to be cleared, got %d invocations", metrics.TotalInvocations)
+	}
+
+	// 恢复后再调用一次，计数应在恢复值基础上累加
+	if err := registry.LoadMetrics(&buf); err != nil {
+		t.Fatalf("LoadMetrics failed: %v", err)
+	}
+	inv.Invoke(context.Background(), "metrics_persist", 5)
+
+	metrics := lambda.GetMetrics()
+	if metrics.TotalInvocations != 4 {
+		t.Errorf("Expected 4 total invocations, got %d", metrics.TotalInvocations)
+	}
+	if metrics.SuccessInvocations != 3 {
+		t.Errorf("Expected 3 success invocations, got %d", metrics.SuccessInvocations)
+	}
+	if metrics.ErrorInvocations != 1 {
+		t.Errorf("Expected 1 error invocation, got %d", metrics.ErrorInvocations)
+	}
+}
+
+func TestLambdaVersionLifecycle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	registry.SetVersionClock(func() time.Time { return now })
+	defer registry.SetVersionClock(nil)
+
+	// 版本记录是全局的，每次运行使用独立名称
+	name := fmt.Sprintf("pricing_%d", time.Now().UnixNano())
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	registry.RegisterLambdaVersion(name, "v1", func(ctx context.Context, input int) (int, error) {
+		return input * 10, nil
+	}, time.Hour, 24*time.Hour)
+	registry.RegisterLambdaVersion(name, "v2", func(ctx context.Context, input int) (int, error) {
+		return input * 20, nil
+	}, time.Hour, 24*time.Hour)
+
+	ctx := context.Background()
+	result, err := registry.InvokeVersion[int, int](ctx, name, "", 1)
+	if err != nil || result.Output != 20 {
+		t.Fatalf("Expected latest version to return 20, got %v (err=%v)", result, err)
+	}
+
+	// 宽限期内旧版本仍正常可用
+	if _, err := registry.InvokeVersion[int, int](ctx, name, "v1", 1); err != nil {
+		t.Fatalf("Expected v1 to be available in grace period: %v", err)
+	}
+	if strings.Contains(logs.String(), "deprecated") {
+		t.Errorf("Expected no deprecation warning yet, got %q", logs.String())
+	}
+
+	now = now.Add(2 * time.Hour)
+	result, err = registry.InvokeVersion[int, int](ctx, name, "v1", 1)
+	if err != nil || result.Output != 10 {
+		t.Fatalf("Expected deprecated v1 to still run, got %v (err=%v)", result, err)
+	}
+	if !strings.Contains(logs.String(), fmt.Sprintf("lambda '%s' version 'v1' is deprecated", name)) {
+		t.Errorf("Expected deprecation warning, got %q", logs.String())
+	}
+	if state, _ := registry.GetVersionState(name, "v1"); state != registry.VersionDeprecated {
+		t.Errorf("Expected v1 deprecated, got %s", state)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, err := registry.InvokeVersion[int, int](ctx, name, "v1", 1); !errors.Is(err, registry.ErrVersionRemoved) {
+		t.Errorf("Expected removed v1 to be rejected with ErrVersionRemoved, got %v", err)
+	}
+	// 移除后的版本也不能再通过注册中心直接调用
+	if _, err := invoker.NewInvoker[int, int]().Invoke(ctx, name+"@v1", 1); !errors.Is(err, core.ErrLambdaNotFound) {
+		t.Errorf("Expected %s@v1 to be unregistered, got %v", name, err)
+	}
+	for _, meta := range registry.ListAll() {
+		if meta.Name == name+"@v1" {
+			t.Errorf("Expected %s@v1 to be gone from ListAll", name)
+		}
+	}
+	if state, _ := registry.GetVersionState(name, "v2"); state != registry.VersionActive {
+		t.Errorf("Expected v2 to stay active, got %s", state)
+	}
+	registry.UnregisterLambda[int, int](name + "@v2")
+}
+
+func TestNamespacedLambdas(t *testing.T) {
+	defer func() {
+		for _, namespace := range []string{"billing", "shipping"} {
+			registry.UnregisterLambda[int, int](registry.QualifiedName(namespace, "process"))
+		}
+	}()
+
+	if err := registry.RegisterNamespacedLambda("billing", "process", func(ctx context.Context, input int) (int, error) {
+		return input * 100, nil
+	}); err != nil {
+		t.Fatalf("Register in billing failed: %v", err)
+	}
+	if err := registry.RegisterNamespacedLambda("shipping", "process", func(ctx context.Context, input int) (int, error) {
+		return input + 1, nil
+	}); err != nil {
+		t.Fatalf("Register in shipping failed: %v", err)
+	}
+
+	ctx := context.Background()
+	billing := invoker.NewInvoker[int, int]().WithNamespace("billing")
+	shipping := invoker.NewInvoker[int, int]().WithNamespace("shipping")
+
+	result, err := billing.Invoke(ctx, "process", 2)
+	if err != nil || result.Output != 200 {
+		t.Errorf("Expected billing/process to return 200, got %v (err=%v)", result, err)
+	}
+	result, err = shipping.Invoke(ctx, "process", 2)
+	if err != nil || result.Output != 3 {
+		t.Errorf("Expected shipping/process to return 3, got %v (err=%v)", result, err)
+	}
+
+	if _, err := invoker.NewInvoker[int, int]().Invoke(ctx, "process", 2); !errors.Is(err, core.ErrLambdaNotFound) {
+		t.Errorf("Expected un-namespaced lookup to miss, got %v", err)
+	}
+
+	namespaces := make(map[string]bool)
+	for _, meta := range registry.ListAll() {
+		if meta.Name == "process" {
+			namespaces[meta.Namespace] = true
+		}
+	}
+	if !namespaces["billing"] || !namespaces["shipping"] || len(namespaces) != 2 {
+		t.Errorf("Expected ListAll to report billing and shipping namespaces, got %v", namespaces)
+	}
+
+	if metas := registry.ListNamespace("billing"); len(metas) != 1 || metas[0].Name != "process" {
+		t.Errorf("Expected one lambda in billing namespace, got %+v", metas)
+	}
+}
+
+func TestNamespacedLambdasRejectSeparator(t *testing.T) {
+	identity := func(ctx context.Context, input int) (int, error) { return input, nil }
+
+	for _, tc := range [][2]string{{"a", "b/c"}, {"a/b", "c"}} {
+		if err := registry.RegisterNamespacedLambda(tc[0], tc[1], identity); err == nil {
+			registry.UnregisterLambda[int, int](registry.QualifiedName(tc[0], tc[1]))
+			t.Errorf("Expected namespace %q name %q to be rejected", tc[0], tc[1])
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected WithNamespace to panic on a namespace containing '/'")
+			}
+		}()
+		invoker.NewInvoker[int, int]().WithNamespace("a/b")
+	}()
+
+	// 普通名称中的分隔符不会被解析为命名空间
+	registry.RegisterOrReplace("plain/slash", identity)
+	defer registry.UnregisterLambda[int, int]("plain/slash")
+
+	for _, meta := range registry.ListAll() {
+		if meta.Name == "slash" || (meta.Name == "plain/slash" && meta.Namespace != "") {
+			t.Errorf("Expected plain/slash to stay un-namespaced, got %+v", meta)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	double := func(ctx context.Context, input int) (int, error) { return input * 2, nil }
+	config := func(name string, retries int) registry.LambdaConfig {
+		return registry.NewLambdaConfig(name, func() *core.Lambda[int, int] {
+			return core.NewLambda(name, double, core.WithRetries(retries))
+		})
+	}
+
+	initial := registry.Reconcile([]registry.LambdaConfig{
+		config("reconcile_keep", 1),
+		config("reconcile_update", 1),
+		config("reconcile_remove", 1),
+	})
+	if len(initial.Added) != 3 {
+		t.Fatalf("Expected 3 lambdas added initially, got %+v", initial)
+	}
+
+	result := registry.Reconcile([]registry.LambdaConfig{
+		config("reconcile_keep", 1),
+		config("reconcile_update", 3),
+		config("reconcile_add", 1),
+	})
+
+	if len(result.Added) != 1 || result.Added[0] != "reconcile_add" {
+		t.Errorf("Expected [reconcile_add] added, got %v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "reconcile_remove" {
+		t.Errorf("Expected [reconcile_remove] removed, got %v", result.Removed)
+	}
+	if len(result.Updated) != 1 || result.Updated[0] != "reconcile_update" {
+		t.Errorf("Expected [reconcile_update] updated, got %v", result.Updated)
+	}
+	if len(result.Unchanged) != 1 || result.Unchanged[0] != "reconcile_keep" {
+		t.Errorf("Expected [reconcile_keep] unchanged, got %v", result.Unchanged)
+	}
+
+	if _, exists := registry.GetLambda[int, int]("reconcile_remove"); exists {
+		t.Error("Expected reconcile_remove to be unregistered")
+	}
+	updated, exists := registry.GetLambda[int, int]("reconcile_update")
+	if !exists || updated.GetOptions().Retries != 3 {
+		t.Errorf("Expected reconcile_update to have 3 retries")
+	}
+	// 未由 Reconcile 注册的lambda不受影响
+	if _, exists := registry.GetLambda[int, int]("math_double"); !exists {
+		t.Error("Expected unmanaged math_double to remain registered")
+	}
+
+	registry.Reconcile(nil)
+}
+
+func TestReconcileDoesNotAdoptExistingLambdas(t *testing.T) {
+	double := func(ctx context.Context, input int) (int, error) { return input * 2, nil }
+	registry.RegisterOrReplace("reconcile_foreign", double)
+	defer registry.UnregisterLambda[int, int]("reconcile_foreign")
+
+	result := registry.Reconcile([]registry.LambdaConfig{
+		registry.NewLambdaConfig("reconcile_foreign", func() *core.Lambda[int, int] {
+			return core.NewLambda("reconcile_foreign", double, core.WithRetries(2))
+		}),
+	})
+	if len(result.Updated) != 1 {
+		t.Fatalf("Expected existing lambda options to be updated, got %+v", result)
+	}
+
+	result = registry.Reconcile(nil)
+	if len(result.Removed) != 0 {
+		t.Errorf("Expected no removals, got %v", result.Removed)
+	}
+	if _, exists := registry.GetLambda[int, int]("reconcile_foreign"); !exists {
+		t.Error("Expected lambda not registered by Reconcile to stay registered")
+	}
+}
+
+func TestReconcileConfigOnlyChange(t *testing.T) {
+	double := func(ctx context.Context, input int) (int, error) { return input * 2, nil }
+	config := func(tier string) registry.LambdaConfig {
+		return registry.NewLambdaConfig("reconcile_config", func() *core.Lambda[int, int] {
+			return core.NewLambda("reconcile_config", double, core.WithConfig("tier", tier))
+		})
+	}
+	defer registry.Reconcile(nil)
+
+	registry.Reconcile([]registry.LambdaConfig{config("gold")})
+
+	result := registry.Reconcile([]registry.LambdaConfig{config("gold")})
+	if len(result.Unchanged) != 1 {
+		t.Errorf("Expected identical config to be unchanged, got %+v", result)
+	}
+
+	result = registry.Reconcile([]registry.LambdaConfig{config("platinum")})
+	if len(result.Updated) != 1 || result.Updated[0] != "reconcile_config" {
+		t.Fatalf("Expected config-only change to be updated, got %+v", result)
+	}
+
+	lambda, exists := registry.GetLambda[int, int]("reconcile_config")
+	if !exists {
+		t.Fatal("Expected reconcile_config to be registered")
+	}
+	if tier, _ := lambda.Config("tier"); tier != "platinum" {
+		t.Errorf("Expected tier platinum, got %v", tier)
+	}
+}
+
+func TestExportImportMeta(t *testing.T) {
+	identity := func(ctx context.Context, input string) (string, error) { return input, nil }
+	registry.RegisterOrReplace("snapshot_removed", identity, core.WithTags("snapshot"))
+
+	data, err := registry.ExportMeta()
+	if err != nil {
+		t.Fatalf("ExportMeta failed: %v", err)
+	}
+
+	var snapshot registry.RegistrySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Snapshot is not valid JSON: %v", err)
+	}
+	found := false
+	for _, entry := range snapshot.Lambdas {
+		if entry.Name == "snapshot_removed" {
+			found = entry.InputType == "string" && len(entry.Tags) == 1 && entry.Tags[0] == "snapshot"
+		}
+	}
+	if !found {
+		t.Errorf("Expected snapshot_removed with its metadata in snapshot")
+	}
+
+	diff, err := registry.ImportMeta(data)
+	if err != nil {
+		t.Fatalf("ImportMeta failed: %v", err)
+	}
+	if len(diff.Missing) != 0 || len(diff.Extra) != 0 {
+		t.Errorf("Expected no diff against unchanged registry, got %+v", diff)
+	}
+
+	registry.UnregisterLambda[string, string]("snapshot_removed")
+	registry.RegisterOrReplace("snapshot_added", identity)
+	defer registry.UnregisterLambda[string, string]("snapshot_added")
+
+	diff, err = registry.ImportMeta(data)
+	if err != nil {
+		t.Fatalf("ImportMeta failed: %v", err)
+	}
+	if len(diff.Missing) != 1 || diff.Missing[0] != "snapshot_removed (string->string)" {
+		t.Errorf("Expected snapshot_removed missing, got %v", diff.Missing)
+	}
+	if len(diff.Extra) != 1 || diff.Extra[0] != "snapshot_added (string->string)" {
+		t.Errorf("Expected snapshot_added extra, got %v", diff.Extra)
+	}
+
+	if _, err := registry.ImportMeta([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid snapshot")
+	}
+}
+
+func TestWatchRegistryEvents(t *testing.T) {
+	first := registry.Watch()
+	defer registry.Unwatch(first)
+	second := registry.Watch()
+	defer registry.Unwatch(second)
+
+	identity := func(ctx context.Context, input int) (int, error) { return input, nil }
+	registry.RegisterLambda("watched", identity)
+	registry.RegisterOrReplace("watched", identity, core.WithTags("v2"))
+	registry.UnregisterLambda[int, int]("watched")
+
+	expected := []struct {
+		eventType registry.RegistryEventType
+		tags      int
+	}{
+		{registry.Registered, 0},
+		{registry.Registered, 1},
+		{registry.Unregistered, 1},
+	}
+
+	for _, events := range []<-chan registry.RegistryEvent{first, second} {
+		for i, want := range expected {
+			var event registry.RegistryEvent
+			// 跳过其它测试并发产生的事件
+			for {
+				select {
+				case event = <-events:
+				case <-time.After(time.Second):
+					t.Fatalf("Timed out waiting for event %d", i)
+				}
+				if event.Meta.Name == "watched" {
+					break
+				}
+			}
+			if event.Type != want.eventType || len(event.Meta.Tags) != want.tags {
+				t.Errorf("Event %d: expected %s with %d tags, got %s %+v", i, want.eventType, want.tags, event.Type, event.Meta)
+			}
+		}
+	}
+}
+
+func TestInvokeJSONCoercesNumbers(t *testing.T) {
+	registry.RegisterOrReplace("json_int_add", func(ctx context.Context, input int64) (int64, error) {
+		return input + 1, nil
+	})
+
+	// 超出 float64 精确表示范围的整数不能经 float64 中转
+	output, err := registry.InvokeJSON(context.Background(), "json_int_add", []byte("9007199254740993"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(output) != "9007199254740994" {
+		t.Errorf("Expected 9007199254740994, got %s", output)
+	}
+
+	output, err = registry.InvokeJSON(context.Background(), "json_int_add", []byte("2.0e1"))
+	if err != nil {
+		t.Fatalf("Unexpected error for integral float: %v", err)
+	}
+	if string(output) != "21" {
+		t.Errorf("Expected 21, got %s", output)
+	}
+
+	if _, err := registry.InvokeJSON(context.Background(), "json_int_add", []byte("1.5")); !errors.Is(err, registry.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for fractional input, got %v", err)
+	}
+}
+
+func TestInvokeJSONAnyInputKeepsIntegers(t *testing.T) {
+	registry.RegisterOrReplace("json_any_describe", func(ctx context.Context, input any) (string, error) {
+		values, ok := input.(map[string]any)
+		if !ok {
+			return "", errors.New("expected object input")
+		}
+		return fmt.Sprintf("%T:%v %T:%v", values["count"], values["count"], values["ratio"], values["ratio"]), nil
+	})
+
+	output, err := registry.InvokeJSON(context.Background(), "json_any_describe", []byte(`{"count": 42, "ratio": 0.5}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var described string
+	if err := json.Unmarshal(output, &described); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if described != "int64:42 float64:0.5" {
+		t.Errorf("Expected int64 and float64 values, got %q", described)
+	}
+}
+
+func TestGlobalCountAndHealth(t *testing.T) {
+	before := registry.GlobalCount()
+	beforeHealth := registry.Health()
+
+	registry.RegisterOrReplace("health_int8", func(ctx context.Context, input int8) (int8, error) {
+		return input, nil
+	})
+	registry.RegisterOrReplace("health_uint16", func(ctx context.Context, input uint16) (string, error) {
+		return fmt.Sprint(input), nil
+	})
+	registry.RegisterOrReplace("health_float32", func(ctx context.Context, input float32) (bool, error) {
+		return input > 0, nil
+	})
+	registry.RegisterNamespacedLambda("health", "float32_extra", func(ctx context.Context, input float32) (bool, error) {
+		return input < 0, nil
+	})
+	defer func() {
+		registry.UnregisterLambda[int8, int8]("health_int8")
+		registry.UnregisterLambda[uint16, string]("health_uint16")
+		registry.UnregisterLambda[float32, bool]("health_float32")
+		registry.UnregisterLambda[float32, bool](registry.QualifiedName("health", "float32_extra"))
+	}()
+
+	if got := registry.GlobalCount(); got != before+4 {
+		t.Errorf("Expected GlobalCount %d, got %d", before+4, got)
+	}
+
+	health := registry.Health()
+	if health.TotalLambdas != registry.GlobalCount() {
+		t.Errorf("Expected TotalLambdas to match GlobalCount, got %d", health.TotalLambdas)
+	}
+	if health.TypeCombinations != beforeHealth.TypeCombinations+3 {
+		t.Errorf("Expected 3 new type combinations, got %d -> %d", beforeHealth.TypeCombinations, health.TypeCombinations)
+	}
+	if health.Namespaces["health"] != 1 {
+		t.Errorf("Expected 1 lambda in namespace 'health', got %v", health.Namespaces)
+	}
+	if health.Namespaces[""] != beforeHealth.Namespaces[""]+3 {
+		t.Errorf("Expected 3 new lambdas in default namespace, got %v", health.Namespaces)
+	}
+
+	// 尚未构建的构造函数同时计入总数与默认命名空间
+	registry.RegisterLambdaWithConstructor("health_lazy", func() *core.Lambda[int8, int8] {
+		return core.NewLambda("health_lazy", func(ctx context.Context, input int8) (int8, error) { return input, nil })
+	})
+	defer registry.UnregisterLambda[int8, int8]("health_lazy")
+
+	health = registry.Health()
+	sum := 0
+	for _, count := range health.Namespaces {
+		sum += count
+	}
+	if sum != health.TotalLambdas || health.TotalLambdas != before+5 {
+		t.Errorf("Expected namespaces to sum to TotalLambdas %d, got %d (%v)", before+5, sum, health.Namespaces)
+	}
+}
+
+func TestRegisterBatchAtomic(t *testing.T) {
+	identity := func(ctx context.Context, input int16) (int16, error) { return input, nil }
+	registry.RegisterOrReplace("batch_existing", identity)
+	defer func() {
+		for _, name := range []string{"batch_existing", "batch_a", "batch_b"} {
+			registry.UnregisterLambda[int16, int16](name)
+		}
+	}()
+
+	defs := []registry.LambdaDef[int16, int16]{
+		{Name: "batch_a", Invoke: identity},
+		{Name: "batch_existing", Invoke: identity},
+		{Name: "batch_b", Invoke: identity, Options: []core.LambdaOption{core.WithTags("batch")}},
+	}
+
+	err := registry.RegisterBatch(defs)
+	if err == nil || !strings.Contains(err.Error(), "batch_existing") {
+		t.Fatalf("Expected error naming the duplicate, got %v", err)
+	}
+	if _, exists := registry.GetLambda[int16, int16]("batch_a"); exists {
+		t.Error("Expected no partial registration in atomic mode")
+	}
+
+	// 非原子模式注册有效的定义并报告失败的定义
+	err = registry.RegisterBatch(defs, registry.WithBatchBestEffort())
+	if err == nil || !strings.Contains(err.Error(), "batch_existing") {
+		t.Errorf("Expected error naming the duplicate, got %v", err)
+	}
+	if _, exists := registry.GetLambda[int16, int16]("batch_b"); !exists {
+		t.Error("Expected batch_b to be registered in best-effort mode")
+	}
+
+	// 替换模式下重名不视为失败
+	if err := registry.RegisterBatch(defs, registry.WithBatchReplace()); err != nil {
+		t.Errorf("Expected replace batch to succeed, got %v", err)
+	}
+}
+
+func TestLocateAcrossTypePairs(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  any
+		output any
+		types  string
+	}{
+		{"math_double", 21, 42, "int->int"},
+		{"string_upper", "locate", "LOCATE", "string->string"},
+	} {
+		descriptor, found := registry.Locate(tc.name)
+		if !found {
+			t.Fatalf("Expected to locate %s", tc.name)
+		}
+		if got := descriptor.InputType.String() + "->" + descriptor.OutputType.String(); got != tc.types {
+			t.Errorf("%s: expected types %s, got %s", tc.name, tc.types, got)
+		}
+
+		output, err := descriptor.Invoke(context.Background(), tc.input)
+		if err != nil {
+			t.Fatalf("%s: invoke failed: %v", tc.name, err)
+		}
+		if output != tc.output {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.output, output)
+		}
+	}
+
+	descriptor, _ := registry.Locate("math_double")
+	if _, err := descriptor.Invoke(context.Background(), "wrong"); !errors.Is(err, registry.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for mistyped input, got %v", err)
+	}
+
+	if _, found := registry.Locate("locate_missing"); found {
+		t.Error("Expected missing lambda not to be located")
+	}
+}
+
+func TestRegistryInvokeMatchesInvoker(t *testing.T) {
+	ctx := context.Background()
+	inv := invoker.NewInvoker[int, int]()
+
+	for _, tc := range []struct {
+		name  string
+		input int
+	}{
+		{"math_double", 21},
+		{"math_factorial", 5},
+		{"math_factorial", -1},
+		{"registry_invoke_missing", 1},
+	} {
+		want, wantErr := inv.Invoke(ctx, tc.name, tc.input)
+		got, gotErr := registry.Invoke[int, int](ctx, tc.name, tc.input)
+
+		if (wantErr == nil) != (gotErr == nil) || (wantErr != nil && wantErr.Error() != gotErr.Error()) {
+			t.Errorf("%s(%d): expected error %v, got %v", tc.name, tc.input, wantErr, gotErr)
+			continue
+		}
+		if (want == nil) != (got == nil) || (want != nil && want.Output != got.Output) {
+			t.Errorf("%s(%d): expected result %+v, got %+v", tc.name, tc.input, want, got)
+		}
+	}
+
+	if _, err := registry.Invoke[int, int](ctx, "registry_invoke_missing", 1); !errors.Is(err, core.ErrLambdaNotFound) {
+		t.Errorf("Expected ErrLambdaNotFound, got %v", err)
+	}
+
+	if got := registry.MustInvoke[string, string](ctx, "string_upper", "must"); got != "MUST" {
+		t.Errorf("Expected MUST, got %q", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustInvoke to panic on error")
+		}
+	}()
+	registry.MustInvoke[int, int](ctx, "math_factorial", -1)
+}
+
+func TestRegisterWithPriorityOrdering(t *testing.T) {
+	ar := &registry.AutoRegisterer{}
+	var order []string
+
+	ar.RegisterHandlerWithPriority("consumer", 10, func() {
+		order = append(order, "consumer")
+		// 依赖 priority_base 已经注册
+		base, ok := registry.GetLambda[int, int]("priority_base")
+		if !ok {
+			t.Error("Expected priority_base to be registered before consumer")
+			return
+		}
+		registry.RegisterOrReplace("priority_consumer", func(ctx context.Context, input int) (int, error) {
+			result, err := base.Invoke(ctx, input)
+			if err != nil {
+				return 0, err
+			}
+			return result.Output + 1, nil
+		})
+	})
+	ar.RegisterHandler(func() { order = append(order, "default-a") })
+	ar.RegisterHandlerWithPriority("base", -10, func() {
+		order = append(order, "base")
+		registry.RegisterOrReplace("priority_base", func(ctx context.Context, input int) (int, error) {
+			return input * 10, nil
+		})
+	})
+	ar.RegisterHandler(func() { order = append(order, "default-b") })
+
+	ar.ExecuteHandlers()
+
+	expected := []string{"base", "default-a", "default-b", "consumer"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Fatalf("Expected order %v, got %v", expected, order)
+	}
+
+	if got := registry.MustInvoke[int, int](context.Background(), "priority_consumer", 4); got != 41 {
+		t.Errorf("Expected 41, got %d", got)
+	}
+}
+
+func TestRegisterWithPriorityNames(t *testing.T) {
+	ar := &registry.AutoRegisterer{}
+	if err := ar.RegisterHandlerWithPriority("seed", 0, func() {}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ar.RegisterHandlerWithPriority("seed", 5, func() {}); err == nil {
+		t.Error("Expected duplicate handler name to be rejected")
+	}
+	// 匿名处理函数不参与去重
+	ar.RegisterHandler(func() {})
+	ar.RegisterHandler(func() {})
+
+	ar.RegisterHandlerWithPriority("broken", 3, func() { panic("boom") })
+	defer func() {
+		r := recover()
+		if msg, _ := r.(string); !strings.Contains(msg, "auto handler 'broken' (priority 3)") || !strings.Contains(msg, "boom") {
+			t.Errorf("Expected panic to name the handler, got %v", r)
+		}
+	}()
+	ar.ExecuteHandlers()
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/scheduler"
+)
+
+func TestScheduleRunsUntilStopped(t *testing.T) {
+	// 由测试控制节拍：无缓冲通道上的发送完成，说明后台goroutine已记录完上一次执行并回到等待状态
+	ticks := make(chan time.Time)
+	scheduler.SetTicker(func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	})
+	defer scheduler.SetTicker(nil)
+
+	job := scheduler.Schedule[int, int]("math_square", 9, time.Hour)
+	for i := 0; i < 3; i++ {
+		ticks <- time.Now()
+	}
+
+	// 第三次节拍被接收时前两次执行已经记录
+	result, err := job.LastResult()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Output != 81 {
+		t.Errorf("Expected 81, got %d", result.Output)
+	}
+
+	job.Stop()
+	if runs := job.Runs(); runs != 3 {
+		t.Fatalf("Expected 3 runs, got %d", runs)
+	}
+
+	// Stop 返回后不再有goroutine接收节拍
+	select {
+	case ticks <- time.Now():
+		t.Error("Expected no receiver for ticks after Stop")
+	default:
+	}
+
+	// 重复 Stop 不应阻塞
+	job.Stop()
+}
+
+func TestScheduleRejectsNonPositiveInterval(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Schedule to panic for a zero interval")
+		}
+	}()
+	scheduler.Schedule[int, int]("math_square", 9, 0)
+}
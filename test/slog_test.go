@@ -0,0 +1,116 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	handler := func(ctx context.Context, input string) (int, error) {
+		if input == "" {
+			return 0, errors.New("empty input")
+		}
+		return len(input), nil
+	}
+
+	lambda := core.NewLambdaWithMiddleware("slog", handler,
+		core.SlogLogger(logger, "string_length",
+			core.WithInputSummary[string, int](func(input string) any { return strings.ToUpper(input) }),
+			core.WithOutputSummary[string, int](func(output int) any { return output }),
+		),
+	)
+
+	lambda.Invoke(context.Background(), "hello")
+	lambda.Invoke(context.Background(), "")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var success, failure map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &success); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+
+	if success["level"] != "INFO" || success["name"] != "string_length" {
+		t.Errorf("Unexpected success log: %v", success)
+	}
+	if success["input"] != "HELLO" || success["output"] != float64(5) {
+		t.Errorf("Expected summarized input/output, got %v", success)
+	}
+	if _, ok := success["duration_ms"]; !ok {
+		t.Error("Expected duration_ms attribute")
+	}
+	if _, ok := success["error"]; ok {
+		t.Error("Expected no error attribute on success")
+	}
+
+	if failure["level"] != "ERROR" || failure["error"] != "empty input" {
+		t.Errorf("Unexpected failure log: %v", failure)
+	}
+}
+
+func TestRequestIDLoggerCorrelatesLogLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var handlerID string
+	lambda := core.NewLambdaWithMiddleware("request_id_log",
+		func(ctx context.Context, input int) (int, error) {
+			handlerID, _ = core.RequestIDFromContext(ctx)
+			return input, nil
+		},
+		core.RequestIDLogger[int, int](logger, "request_id_log"),
+	)
+
+	result, err := lambda.Invoke(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var ids []string
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log line: %v", err)
+		}
+		id, _ := entry["request_id"].(string)
+		ids = append(ids, id)
+	}
+
+	if ids[0] == "" || ids[0] != ids[1] {
+		t.Errorf("Expected the same request ID on both lines, got %v", ids)
+	}
+	if handlerID != ids[0] {
+		t.Errorf("Expected handler to see request ID %q, got %q", ids[0], handlerID)
+	}
+	if result.Metadata[core.RequestIDMetadataKey] != ids[0] {
+		t.Errorf("Expected request ID in result metadata, got %v", result.Metadata)
+	}
+
+	// 调用方提供的请求ID被沿用
+	buf.Reset()
+	lambda.Invoke(core.WithRequestID(context.Background(), "req-fixed"), 1)
+	if !strings.Contains(buf.String(), `"request_id":"req-fixed"`) {
+		t.Errorf("Expected caller request ID in logs, got %s", buf.String())
+	}
+}
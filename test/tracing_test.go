@@ -0,0 +1,180 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ZHLX2005/minilambda/core"
+	"github.com/ZHLX2005/minilambda/tracing"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	recorder := tracing.NewRecorder()
+
+	var childHasSpan bool
+	handler := func(ctx context.Context, input int) (int, error) {
+		_, childHasSpan = tracing.SpanFromContext(ctx)
+		if input < 0 {
+			return 0, errors.New("negative input")
+		}
+		return input * 2, nil
+	}
+
+	lambda := core.NewLambdaWithMiddleware("traced", handler, tracing.Trace[int, int](recorder, "traced_double"))
+
+	lambda.Invoke(context.Background(), 1)
+	lambda.Invoke(context.Background(), -1)
+
+	if !childHasSpan {
+		t.Error("Expected span context to be passed to next")
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	for _, span := range spans {
+		if span.Name != "traced_double" {
+			t.Errorf("Expected span name 'traced_double', got '%s'", span.Name)
+		}
+		if span.Attributes["lambda.name"] != "traced_double" {
+			t.Errorf("Expected lambda.name attribute, got %v", span.Attributes["lambda.name"])
+		}
+		if span.Attributes["lambda.input_type"] != "int" {
+			t.Errorf("Expected lambda.input_type 'int', got %v", span.Attributes["lambda.input_type"])
+		}
+		if _, ok := span.Attributes["lambda.duration_ms"]; !ok {
+			t.Error("Expected lambda.duration_ms attribute")
+		}
+	}
+
+	if spans[0].Status != tracing.StatusOK {
+		t.Errorf("Expected first span status OK, got %v", spans[0].Status)
+	}
+	if spans[1].Status != tracing.StatusError || len(spans[1].Errors) != 1 {
+		t.Errorf("Expected second span to record an error, got status %v errors %v", spans[1].Status, spans[1].Errors)
+	}
+}
+
+func TestSampledTrace(t *testing.T) {
+	handler := func(ctx context.Context, input int) (int, error) {
+		return input, nil
+	}
+
+	// 固定的伪随机序列，保证结果可复现
+	values := []float64{0.0, 0.25, 0.5, 0.75, 0.99}
+	sequence := func() func() float64 {
+		i := 0
+		return func() float64 {
+			v := values[i%len(values)]
+			i++
+			return v
+		}
+	}
+
+	tests := []struct {
+		rate     float64
+		expected int
+	}{
+		{0, 0},
+		{1, 5},
+		{0.5, 2},
+	}
+
+	for _, test := range tests {
+		recorder := tracing.NewRecorder()
+		lambda := core.NewLambdaWithMiddleware("sampled", handler,
+			tracing.SampledTrace[int, int](recorder, "sampled", test.rate, sequence()),
+		)
+
+		for i := 0; i < 5; i++ {
+			lambda.Invoke(context.Background(), i)
+		}
+
+		if got := len(recorder.Spans()); got != test.expected {
+			t.Errorf("rate=%v: expected %d spans, got %d", test.rate, test.expected, got)
+		}
+	}
+}
+
+func TestExportJaeger(t *testing.T) {
+	recorder := tracing.NewRecorder()
+
+	inner := core.NewLambdaWithMiddleware("jaeger_inner",
+		func(ctx context.Context, input int) (int, error) { return 0, errors.New("boom") },
+		tracing.Trace[int, int](recorder, "inner"),
+	)
+	outer := core.NewLambdaWithMiddleware("jaeger_outer",
+		func(ctx context.Context, input int) (int, error) {
+			inner.Invoke(ctx, input)
+			return input, nil
+		},
+		tracing.Trace[int, int](recorder, "outer"),
+	)
+	outer.Invoke(context.Background(), 1)
+
+	var buf bytes.Buffer
+	if err := tracing.ExportJaeger(recorder.Spans(), &buf); err != nil {
+		t.Fatalf("ExportJaeger failed: %v", err)
+	}
+
+	var batch struct {
+		Data []struct {
+			TraceID string `json:"traceID"`
+			Spans   []struct {
+				TraceID       string `json:"traceID"`
+				SpanID        string `json:"spanID"`
+				OperationName string `json:"operationName"`
+				References    []struct {
+					RefType string `json:"refType"`
+					SpanID  string `json:"spanID"`
+				} `json:"references"`
+				Tags []struct {
+					Key string `json:"key"`
+				} `json:"tags"`
+			} `json:"spans"`
+			Processes map[string]any `json:"processes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &batch); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if len(batch.Data) != 1 || len(batch.Data[0].Spans) != 2 {
+		t.Fatalf("Expected one trace with two spans, got %s", buf.String())
+	}
+
+	spans := make(map[string]int)
+	for i, span := range batch.Data[0].Spans {
+		if len(span.TraceID) != 32 || len(span.SpanID) != 16 || span.OperationName == "" {
+			t.Errorf("Span missing required fields: %+v", span)
+		}
+		if span.TraceID != batch.Data[0].TraceID {
+			t.Errorf("Expected span traceID %s, got %s", batch.Data[0].TraceID, span.TraceID)
+		}
+		spans[span.OperationName] = i
+	}
+
+	innerSpan := batch.Data[0].Spans[spans["inner"]]
+	outerSpan := batch.Data[0].Spans[spans["outer"]]
+	if len(innerSpan.References) != 1 || innerSpan.References[0].RefType != "CHILD_OF" || innerSpan.References[0].SpanID != outerSpan.SpanID {
+		t.Errorf("Expected inner span to reference outer span, got %+v", innerSpan.References)
+	}
+
+	hasError := false
+	for _, tag := range innerSpan.Tags {
+		if tag.Key == "error" {
+			hasError = true
+		}
+	}
+	if !hasError {
+		t.Error("Expected error tag on failed span")
+	}
+	if _, ok := batch.Data[0].Processes["p1"]; !ok {
+		t.Error("Expected process p1 in trace")
+	}
+}
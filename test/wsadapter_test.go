@@ -0,0 +1,262 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/registry"
+	"github.com/ZHLX2005/minilambda/wsadapter"
+)
+
+// wsClient 测试用的最小 WebSocket 客户端
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server) *wsClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Handshake write failed: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("Handshake read failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected Sec-WebSocket-Accept %q", accept)
+	}
+
+	return &wsClient{conn: conn, reader: reader}
+}
+
+// send 发送一个带掩码的帧
+func (c *wsClient) send(t *testing.T, opcode byte, payload []byte) {
+	t.Helper()
+
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{0x80 | opcode}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+}
+
+// receive 读取一个服务端帧
+func (c *wsClient) receive(t *testing.T) (byte, []byte) {
+	t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("Read payload failed: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocketAdapterStreamsResults(t *testing.T) {
+	server := httptest.NewServer(wsadapter.Handler[string, string]("string_reverse"))
+	defer server.Close()
+
+	client := dialWebSocket(t, server)
+	defer client.conn.Close()
+
+	for _, tc := range []struct {
+		frame  string
+		output string
+		err    string
+	}{
+		{`"abc"`, "cba", ""},
+		{`not json`, "", "invalid payload"},
+		{`"hello"`, "olleh", ""},
+		{`"` + strings.Repeat("x", 200) + `"`, strings.Repeat("x", 200), ""},
+	} {
+		client.send(t, 0x1, []byte(tc.frame))
+
+		opcode, payload := client.receive(t)
+		if opcode != 0x1 {
+			t.Fatalf("Expected text frame, got opcode %d", opcode)
+		}
+		var response wsadapter.Response[string]
+		if err := json.Unmarshal(payload, &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Output != tc.output || !strings.Contains(response.Error, tc.err) || (tc.err == "") != (response.Error == "") {
+			t.Errorf("Frame %s: unexpected response %+v", tc.frame, response)
+		}
+	}
+
+	client.send(t, 0x9, []byte("ping"))
+	if opcode, payload := client.receive(t); opcode != 0xA || string(payload) != "ping" {
+		t.Errorf("Expected pong, got opcode %d payload %q", opcode, payload)
+	}
+
+	client.send(t, 0x8, binary.BigEndian.AppendUint16(nil, 1000))
+	if opcode, _ := client.receive(t); opcode != 0x8 {
+		t.Errorf("Expected close frame, got opcode %d", opcode)
+	}
+}
+
+func TestWebSocketAdapterCancelsOnDisconnect(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	registry.RegisterOrReplace("ws_slow", func(ctx context.Context, input string) (string, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return "", ctx.Err()
+		case <-time.After(5 * time.Second):
+			return input, nil
+		}
+	})
+
+	server := httptest.NewServer(wsadapter.Handler[string, string]("ws_slow"))
+	defer server.Close()
+
+	client := dialWebSocket(t, server)
+	client.send(t, 0x1, []byte(`"wait"`))
+	<-started
+	client.conn.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected in-flight invocation to be cancelled after disconnect")
+	}
+}
+
+func TestWebSocketAdapterSendsNothingAfterClose(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	registry.RegisterOrReplace("ws_sluggish", func(ctx context.Context, input string) (string, error) {
+		// 忽略取消，直到测试收到关闭帧后才返回，模拟关闭时仍在进行的调用
+		close(started)
+		<-release
+		return input, nil
+	})
+
+	server := httptest.NewServer(wsadapter.Handler[string, string]("ws_sluggish"))
+	defer server.Close()
+
+	client := dialWebSocket(t, server)
+	defer client.conn.Close()
+
+	client.send(t, 0x1, []byte(`"late"`))
+	<-started
+	client.send(t, 0x8, binary.BigEndian.AppendUint16(nil, 1000))
+
+	opcode, _ := client.receive(t)
+	close(release)
+	if opcode != 0x8 {
+		t.Fatalf("Expected close frame, got opcode %d", opcode)
+	}
+
+	client.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if rest, err := io.ReadAll(client.reader); err != nil || len(rest) != 0 {
+		t.Errorf("Expected connection to end after close frame, got %d bytes (err=%v)", len(rest), err)
+	}
+}
+
+func TestWebSocketAdapterRejectsOversizedClosePayload(t *testing.T) {
+	server := httptest.NewServer(wsadapter.Handler[string, string]("string_reverse"))
+	defer server.Close()
+
+	client := dialWebSocket(t, server)
+	defer client.conn.Close()
+
+	client.send(t, 0x8, append(binary.BigEndian.AppendUint16(nil, 1000), strings.Repeat("x", 130)...))
+
+	opcode, payload := client.receive(t)
+	if opcode != 0x8 || len(payload) < 2 || binary.BigEndian.Uint16(payload) != 1002 {
+		t.Errorf("Expected close frame with protocol error 1002, got opcode %d payload %v", opcode, payload)
+	}
+}
+
+func TestWebSocketAdapterValidatesCloseAndText(t *testing.T) {
+	server := httptest.NewServer(wsadapter.Handler[string, string]("string_reverse"))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		desc    string
+		opcode  byte
+		payload []byte
+		want    uint16
+	}{
+		{"reserved close code 1005", 0x8, binary.BigEndian.AppendUint16(nil, 1005), 1002},
+		{"reserved close code 1006", 0x8, binary.BigEndian.AppendUint16(nil, 1006), 1002},
+		{"unassigned close code", 0x8, binary.BigEndian.AppendUint16(nil, 2000), 1002},
+		{"invalid close reason", 0x8, append(binary.BigEndian.AppendUint16(nil, 1000), 0xFF), 1002},
+		{"application close code", 0x8, append(binary.BigEndian.AppendUint16(nil, 4000), "bye"...), 4000},
+		{"invalid UTF-8 text", 0x1, []byte{'"', 0xC3, 0x28, '"'}, 1007},
+	} {
+		client := dialWebSocket(t, server)
+		client.send(t, tc.opcode, tc.payload)
+
+		opcode, payload := client.receive(t)
+		if opcode != 0x8 || len(payload) < 2 || binary.BigEndian.Uint16(payload) != tc.want {
+			t.Errorf("%s: expected close %d, got opcode %d payload %v", tc.desc, tc.want, opcode, payload)
+		}
+		client.conn.Close()
+	}
+}
+
+func TestWebSocketAdapterRejectsPlainHTTP(t *testing.T) {
+	server := httptest.NewServer(wsadapter.Handler[string, string]("string_reverse"))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for non-upgrade request, got %d", resp.StatusCode)
+	}
+}
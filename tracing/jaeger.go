@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// jaegerBatch Jaeger 查询接口（/api/traces）返回的 JSON 结构
+type jaegerBatch struct {
+	Data []jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"` // 微秒
+	Duration      int64             `json:"duration"`  // 微秒
+	Tags          []jaegerTag       `json:"tags"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerTag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jaegerProcess struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []jaegerTag `json:"tags"`
+}
+
+// jaegerProcessID 所有span共用的进程ID
+const jaegerProcessID = "p1"
+
+// ExportJaeger 以 Jaeger JSON 格式导出span，按 TraceID 分组
+// 输出结构与 Jaeger UI 可导入的 /api/traces 响应一致，服务名为 "minilambda"
+func ExportJaeger(spans []RecordedSpan, w io.Writer) error {
+	traces := make(map[string]*jaegerTrace)
+	var order []string
+
+	for _, span := range spans {
+		trace, exists := traces[span.TraceID]
+		if !exists {
+			trace = &jaegerTrace{
+				TraceID: span.TraceID,
+				Processes: map[string]jaegerProcess{
+					jaegerProcessID: {ServiceName: "minilambda", Tags: []jaegerTag{}},
+				},
+			}
+			traces[span.TraceID] = trace
+			order = append(order, span.TraceID)
+		}
+		trace.Spans = append(trace.Spans, toJaegerSpan(span))
+	}
+
+	batch := jaegerBatch{Data: make([]jaegerTrace, 0, len(order))}
+	for _, traceID := range order {
+		batch.Data = append(batch.Data, *traces[traceID])
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(batch)
+}
+
+// toJaegerSpan 转换单个span
+func toJaegerSpan(span RecordedSpan) jaegerSpan {
+	result := jaegerSpan{
+		TraceID:       span.TraceID,
+		SpanID:        span.SpanID,
+		OperationName: span.Name,
+		References:    []jaegerReference{},
+		StartTime:     span.StartTime.UnixMicro(),
+		Duration:      span.EndTime.Sub(span.StartTime).Microseconds(),
+		Tags:          []jaegerTag{},
+		ProcessID:     jaegerProcessID,
+	}
+
+	if span.Parent != nil {
+		result.References = append(result.References, jaegerReference{
+			RefType: "CHILD_OF",
+			TraceID: span.Parent.TraceID,
+			SpanID:  span.Parent.SpanID,
+		})
+	}
+
+	keys := make([]string, 0, len(span.Attributes))
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Tags = append(result.Tags, jaegerTagOf(key, span.Attributes[key]))
+	}
+
+	if span.Status == StatusError {
+		result.Tags = append(result.Tags, jaegerTag{Key: "error", Type: "bool", Value: true})
+		if span.StatusDescription != "" {
+			result.Tags = append(result.Tags, jaegerTag{Key: "otel.status_description", Type: "string", Value: span.StatusDescription})
+		}
+	}
+
+	return result
+}
+
+// jaegerTagOf 按值类型生成 Jaeger 标签
+func jaegerTagOf(key string, value any) jaegerTag {
+	switch v := value.(type) {
+	case string:
+		return jaegerTag{Key: key, Type: "string", Value: v}
+	case bool:
+		return jaegerTag{Key: key, Type: "bool", Value: v}
+	case int:
+		return jaegerTag{Key: key, Type: "int64", Value: int64(v)}
+	case int64:
+		return jaegerTag{Key: key, Type: "int64", Value: v}
+	case float64:
+		return jaegerTag{Key: key, Type: "float64", Value: v}
+	default:
+		return jaegerTag{Key: key, Type: "string", Value: fmt.Sprint(v)}
+	}
+}
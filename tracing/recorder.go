@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// RecordedSpan 内存中记录的span
+type RecordedSpan struct {
+	TraceID           string // 32 位十六进制，子span继承父span的 TraceID
+	SpanID            string // 16 位十六进制
+	Name              string
+	Parent            *RecordedSpan
+	Attributes        map[string]any
+	Status            StatusCode
+	StatusDescription string
+	Errors            []error
+	StartTime         time.Time
+	EndTime           time.Time
+}
+
+// Recorder 内存追踪器，记录已结束的span，主要用于测试
+type Recorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// NewRecorder 创建内存追踪器
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// spanContextKey 当前span在 context 中的键
+type spanContextKey struct{}
+
+// Start 开始一个span，context 中已有span时作为其子span
+func (r *Recorder) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	span := &recordingSpan{
+		recorder: r,
+		data: &RecordedSpan{
+			SpanID:     randomHex(8),
+			Name:       spanName,
+			Attributes: make(map[string]any),
+			StartTime:  time.Now(),
+		},
+	}
+
+	if parent, ok := ctx.Value(spanContextKey{}).(*recordingSpan); ok {
+		span.data.Parent = parent.data
+		span.data.TraceID = parent.data.TraceID
+	} else {
+		span.data.TraceID = randomHex(16)
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Spans 返回已结束span的副本
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, span := range r.spans {
+		spans[i] = *span
+	}
+	return spans
+}
+
+// Reset 清空已记录的span
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+// SpanFromContext 返回 context 中当前的span
+func SpanFromContext(ctx context.Context) (Span, bool) {
+	span, ok := ctx.Value(spanContextKey{}).(*recordingSpan)
+	return span, ok
+}
+
+// recordingSpan Recorder 创建的span
+type recordingSpan struct {
+	mu       sync.Mutex
+	recorder *Recorder
+	data     *RecordedSpan
+	ended    bool
+}
+
+// SetAttributes 设置属性
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+}
+
+// SetStatus 设置状态
+func (s *recordingSpan) SetStatus(code StatusCode, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Status = code
+	s.data.StatusDescription = description
+}
+
+// RecordError 记录错误
+func (s *recordingSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Errors = append(s.data.Errors, err)
+}
+
+// End 结束span并提交给 Recorder
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	s.mu.Unlock()
+
+	s.recorder.mu.Lock()
+	s.recorder.spans = append(s.recorder.spans, s.data)
+	s.recorder.mu.Unlock()
+}
+
+// randomHex 返回 n 字节随机数的十六进制表示
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/ZHLX2005/minilambda/core"
+)
+
+// StatusCode span状态码
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Attribute span属性
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span 追踪span
+// 接口与 OpenTelemetry 的 trace.Span 对齐，便于通过适配器接入
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	SetStatus(code StatusCode, description string)
+	RecordError(err error)
+	End()
+}
+
+// Tracer 追踪器
+// 接口与 OpenTelemetry 的 trace.Tracer 对齐，便于通过适配器接入
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Trace 追踪中间件
+// 为每次调用创建一个子span，并把子span的context传给 next
+func Trace[I any, O any](tracer Tracer, spanName string) core.Middleware[I, O] {
+	inputType := reflect.TypeOf((*I)(nil)).Elem().String()
+
+	return func(ctx context.Context, input I, next core.InvokeFunc[I, O]) (O, error) {
+		ctx, span := tracer.Start(ctx, spanName)
+		defer span.End()
+
+		start := time.Now()
+		output, err := next(ctx, input)
+
+		span.SetAttributes(
+			Attribute{Key: "lambda.name", Value: spanName},
+			Attribute{Key: "lambda.input_type", Value: inputType},
+			Attribute{Key: "lambda.duration_ms", Value: float64(core.Since(start)) / float64(time.Millisecond)},
+		)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(StatusError, err.Error())
+		} else {
+			span.SetStatus(StatusOK, "")
+		}
+
+		return output, err
+	}
+}
+
+// SampledTrace 采样追踪中间件
+// 只为 rate 比例的调用创建span；random 返回 [0,1) 的随机数，为空时使用 math/rand
+func SampledTrace[I any, O any](tracer Tracer, spanName string, rate float64, random func() float64) core.Middleware[I, O] {
+	if random == nil {
+		random = rand.Float64
+	}
+	traced := Trace[I, O](tracer, spanName)
+
+	return func(ctx context.Context, input I, next core.InvokeFunc[I, O]) (O, error) {
+		if random() < rate {
+			return traced(ctx, input, next)
+		}
+		return next(ctx, input)
+	}
+}
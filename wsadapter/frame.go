@@ -0,0 +1,193 @@
+package wsadapter
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// websocketGUID 计算 Sec-WebSocket-Accept 使用的固定 GUID（RFC 6455 1.3）
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// 帧操作码
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// 关闭状态码
+const (
+	closeNormal         = 1000
+	closeProtocolError  = 1002
+	closeInvalidPayload = 1007
+	closeTooLarge       = 1009
+)
+
+var (
+	// errProtocol 客户端违反协议
+	errProtocol = errors.New("websocket protocol error")
+	// errTooLarge 消息超过大小上限
+	errTooLarge = errors.New("websocket message too large")
+)
+
+// AcceptKey 根据客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains 判断以逗号分隔的请求头中是否包含指定值（不区分大小写）
+func headerContains(header http.Header, name, value string) bool {
+	for _, field := range header.Values(name) {
+		for _, part := range strings.Split(field, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkHandshake 校验升级请求，返回客户端的 Sec-WebSocket-Key
+func checkHandshake(r *http.Request) (string, error) {
+	if r.Method != http.MethodGet {
+		return "", fmt.Errorf("method %s not allowed", r.Method)
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return "", errors.New("not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return "", errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return "", errors.New("missing Sec-WebSocket-Key")
+	}
+	return key, nil
+}
+
+// frame 一个已解码的帧
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame 读取一个客户端帧，客户端帧必须带掩码
+func readFrame(r *bufio.Reader, maxSize int64) (frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: header[0]&0x80 != 0, opcode: header[0] & 0x0F}
+	if header[0]&0x70 != 0 || header[1]&0x80 == 0 {
+		// 未协商扩展时 RSV 位必须为 0，客户端帧必须带掩码
+		return frame{}, errProtocol
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if length < 0 || length > maxSize {
+		return frame{}, errTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return frame{}, err
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return frame{}, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+// writeFrame 写出一个不带掩码的服务端帧
+func writeFrame(w *bufio.Writer, opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// validCloseCode 判断客户端关闭帧中的状态码是否允许出现在线路上（RFC 6455 7.4）
+// 1005、1006、1015 只供本地使用，其余未分配的 1xxx、2xxx 以及 5000 以上的状态码同样无效。
+func validCloseCode(code uint16) bool {
+	switch {
+	case code >= 1000 && code <= 1003:
+		return true
+	case code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseClose 校验客户端关闭帧的负载，返回应答的关闭帧负载与是否合法
+// 负载为空表示未携带状态码，以 1000 应答；否则状态码必须有效且原因必须是合法的 UTF-8。
+func parseClose(payload []byte) ([]byte, bool) {
+	switch {
+	case len(payload) == 0:
+		return closePayload(closeNormal), true
+	case len(payload) == 1 || len(payload) > 125:
+		return nil, false
+	}
+
+	code := binary.BigEndian.Uint16(payload)
+	if !validCloseCode(code) || !utf8.Valid(payload[2:]) {
+		return nil, false
+	}
+	return closePayload(code), true
+}
+
+// closePayload 编码关闭帧的状态码
+func closePayload(code uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, code)
+}
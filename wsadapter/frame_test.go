@@ -0,0 +1,72 @@
+package wsadapter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// maskFrame 编码一个带掩码的客户端帧
+func maskFrame(fin bool, opcode byte, payload []byte) []byte {
+	first := opcode & 0x0F
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	mask := [4]byte{0xA1, 0xB2, 0xC3, 0xD4}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// FuzzReadFrame 任意输入都不能让 readFrame panic 或返回超过上限的负载，
+// 合法编码的帧必须原样解出
+func FuzzReadFrame(f *testing.F) {
+	const maxSize = 1 << 12
+
+	f.Add([]byte{}, byte(opText), true)
+	f.Add([]byte("hello"), byte(opText), false)
+	f.Add(make([]byte, 200), byte(opBinary), true)
+	f.Add([]byte{0x03, 0xE8}, byte(opClose), true)
+	f.Add([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, byte(0x7F), true)
+
+	f.Fuzz(func(t *testing.T, data []byte, opcode byte, fin bool) {
+		// 把数据直接当作线路字节解析
+		if fr, err := readFrame(bufio.NewReader(bytes.NewReader(data)), maxSize); err == nil && len(fr.payload) > maxSize {
+			t.Fatalf("readFrame returned %d bytes, limit %d", len(fr.payload), maxSize)
+		}
+
+		// 把数据作为负载编码后解析
+		encoded := maskFrame(fin, opcode, data)
+		fr, err := readFrame(bufio.NewReader(bytes.NewReader(encoded)), maxSize)
+		switch {
+		case len(data) > maxSize:
+			if err != errTooLarge {
+				t.Fatalf("Expected errTooLarge for %d bytes, got %v", len(data), err)
+			}
+		case err != nil:
+			t.Fatalf("Failed to read a valid frame: %v", err)
+		case fr.fin != fin || fr.opcode != opcode&0x0F || !bytes.Equal(fr.payload, data):
+			t.Fatalf("Round trip mismatch: got fin=%v opcode=%d payload=%x", fr.fin, fr.opcode, fr.payload)
+		}
+
+		// 关闭帧负载的校验不能 panic，合法时应答必须是两字节状态码
+		if reply, ok := parseClose(data); ok && len(reply) != 2 {
+			t.Fatalf("Expected a 2-byte close reply, got %x", reply)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package wsadapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ZHLX2005/minilambda/invoker"
+)
+
+// DefaultMaxMessageSize 默认的单条消息大小上限
+const DefaultMaxMessageSize = 1 << 20
+
+// Response 每个请求帧对应的响应帧内容
+type Response[O any] struct {
+	Output O      `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Options 适配器配置
+type Options struct {
+	// 单条消息（含分片）的大小上限
+	MaxMessageSize int64
+}
+
+// Option 适配器选项函数
+type Option func(*Options)
+
+// WithMaxMessageSize 设置单条消息的大小上限
+func WithMaxMessageSize(size int64) Option {
+	return func(opts *Options) {
+		opts.MaxMessageSize = size
+	}
+}
+
+// Handler 返回以 WebSocket 调用lambda的 HTTP 处理器
+// 连接建立后，客户端发送的每条文本（或二进制）消息以 JSON 解码为 I 并调用名为 name 的lambda，
+// 结果按请求顺序以 JSON 编码的 Response 返回。单条消息的解码或调用失败只影响对应的响应，连接保持打开；
+// 客户端断开或发送关闭帧时取消正在进行的调用。
+func Handler[I any, O any](name string, opts ...Option) http.Handler {
+	options := &Options{MaxMessageSize: DefaultMaxMessageSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	inv := invoker.NewInvoker[I, O]()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := checkHandshake(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "websocket upgrade not supported", http.StatusInternalServerError)
+			return
+		}
+		netConn, rw, err := hijacker.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer netConn.Close()
+
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", AcceptKey(key))
+		if err := rw.Flush(); err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		c := &conn{rw: rw}
+		messages := make(chan []byte)
+		go func() {
+			defer close(messages)
+			defer cancel()
+			c.readMessages(ctx, options.MaxMessageSize, messages)
+		}()
+
+		for message := range messages {
+			var response Response[O]
+
+			var input I
+			if err := json.Unmarshal(message, &input); err != nil {
+				response.Error = fmt.Sprintf("invalid payload: %v", err)
+			} else if result, err := inv.Invoke(ctx, name, input); err != nil {
+				response.Error = err.Error()
+			} else {
+				response.Output = result.Output
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				data, _ = json.Marshal(Response[O]{Error: fmt.Sprintf("failed to encode output: %v", err)})
+			}
+			if err := c.write(opText, data); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// errConnClosed 关闭帧发出后不再写出任何帧
+var errConnClosed = errors.New("websocket: close frame already sent")
+
+// conn 已升级的连接，写操作由互斥锁串行化
+type conn struct {
+	rw      *bufio.ReadWriter
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// write 写出一帧，关闭帧发出后的写入被丢弃并返回 errConnClosed（RFC 6455 §5.5.1）
+func (c *conn) write(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return errConnClosed
+	}
+	if opcode == opClose {
+		c.closed = true
+	}
+	return writeFrame(c.rw.Writer, opcode, payload)
+}
+
+// readMessages 读取消息并发送到 messages，处理控制帧与分片，连接关闭或出错时返回
+func (c *conn) readMessages(ctx context.Context, maxSize int64, messages chan<- []byte) {
+	var message []byte
+	var messageOp byte
+	inMessage := false
+
+	for {
+		f, err := readFrame(c.rw.Reader, maxSize)
+		if err != nil {
+			switch {
+			case errors.Is(err, errProtocol):
+				c.write(opClose, closePayload(closeProtocolError))
+			case errors.Is(err, errTooLarge):
+				c.write(opClose, closePayload(closeTooLarge))
+			}
+			return
+		}
+
+		switch f.opcode {
+		case opClose:
+			reply, ok := parseClose(f.payload)
+			if !f.fin || !ok {
+				reply = closePayload(closeProtocolError)
+			}
+			c.write(opClose, reply)
+			return
+		case opPing:
+			if !f.fin || len(f.payload) > 125 {
+				c.write(opClose, closePayload(closeProtocolError))
+				return
+			}
+			c.write(opPong, f.payload)
+			continue
+		case opPong:
+			continue
+		case opText, opBinary:
+			if inMessage {
+				c.write(opClose, closePayload(closeProtocolError))
+				return
+			}
+			message, messageOp, inMessage = f.payload, f.opcode, true
+		case opContinuation:
+			if !inMessage {
+				c.write(opClose, closePayload(closeProtocolError))
+				return
+			}
+			message = append(message, f.payload...)
+		default:
+			c.write(opClose, closePayload(closeProtocolError))
+			return
+		}
+
+		if int64(len(message)) > maxSize {
+			c.write(opClose, closePayload(closeTooLarge))
+			return
+		}
+		if !f.fin {
+			continue
+		}
+		// 文本消息必须是合法的 UTF-8（RFC 6455 8.1）
+		if messageOp == opText && !utf8.Valid(message) {
+			c.write(opClose, closePayload(closeInvalidPayload))
+			return
+		}
+
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return
+		}
+		message, inMessage = nil, false
+	}
+}